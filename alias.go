@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// aliasesFile maps memorable names to note IDs, one "name id" pair per
+// line. Names are matched ignoring case, with spaces read as dashes.
+const aliasesFile = ".aliases"
+
+func readAliases(zettelHome string) (map[string]string, error) {
+	content, err := os.ReadFile(filepath.Join(zettelHome, aliasesFile))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	aliases := map[string]string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			aliases[fields[0]] = fields[1]
+		}
+	}
+	return aliases, nil
+}
+
+func writeAliases(zettelHome string, aliases map[string]string) error {
+	var b strings.Builder
+	for _, name := range sortedKeys(aliases) {
+		fmt.Fprintf(&b, "%s %s\n", name, aliases[name])
+	}
+	return stageWrite(zettelHome, filepath.Join(zettelHome, aliasesFile), []byte(b.String()), "aliases")
+}
+
+// aliasKey is the form alias names are compared in.
+func aliasKey(name string) string {
+	return strings.ToLower(slugify(name))
+}
+
+// lookupAlias returns the note name is an alias of.
+func lookupAlias(aliases map[string]string, name string) (string, bool) {
+	key := aliasKey(name)
+	for alias, id := range aliases {
+		if aliasKey(alias) == key {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// addAlias names the note id with alias, or removes alias when id is empty.
+func addAlias(zettelHome, id, alias string) error {
+	aliases, err := readAliases(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading aliases: %w", err)
+	}
+
+	var existing string
+	for name := range aliases {
+		if aliasKey(name) == aliasKey(alias) {
+			existing = name
+		}
+	}
+
+	if id == "" {
+		if existing == "" {
+			return notFoundf("No such alias: %v", alias)
+		}
+		delete(aliases, existing)
+	} else {
+		if alias = slugify(alias); alias == "" || strings.ContainsAny(alias, "[]|#") || strings.ContainsFunc(alias, unicode.IsSpace) {
+			return usagef("Invalid alias: %q", alias)
+		}
+		if existing != "" && aliases[existing] != id {
+			return fmt.Errorf("Alias %s already names %s", existing, aliases[existing])
+		}
+		if _, err := os.Stat(notePath(zettelHome, id)); os.IsNotExist(err) {
+			return notFoundf("Note does not exist: %v", id)
+		}
+		delete(aliases, existing)
+		aliases[alias] = id
+	}
+
+	if err := writeAliases(zettelHome, aliases); err != nil {
+		return fmt.Errorf("Error writing aliases: %w", err)
+	}
+	if id == "" {
+		fmt.Println("Removed alias", existing)
+	} else {
+		fmt.Printf("Aliased %s -> %s\n", alias, id)
+	}
+	return nil
+}
+
+// retargetAliases points the aliases of oldID at newID, or drops them when
+// newID is empty.
+func retargetAliases(zettelHome, oldID, newID string) error {
+	aliases, err := readAliases(zettelHome)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for name, id := range aliases {
+		if id != oldID {
+			continue
+		}
+		if newID == "" {
+			delete(aliases, name)
+		} else {
+			aliases[name] = newID
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return writeAliases(zettelHome, aliases)
+}
+
+func listAliases(zettelHome string) error {
+	aliases, err := readAliases(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading aliases: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(aliases)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range sortedKeys(aliases) {
+		fmt.Fprintf(w, "%s\t%s\n", name, aliases[name])
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// archiveDir is the subdirectory of the notes directory archived notes are
+// moved to. list and search skip it unless asked not to.
+const archiveDir = "archive"
+
+// listArchivedIDs returns the IDs of the archived notes, prefixed with
+// "archive/" so that notePath and readNote resolve them.
+func listArchivedIDs(zettelHome string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(zettelHome, archiveDir, "*"+noteExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !isIgnored(zettelHome, path, false) {
+			ids = append(ids, filepath.Join(archiveDir, noteID(path)))
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// archiveNote moves a note into the archive directory, or back out of it
+// when unarchive is set.
+func archiveNote(zettelHome, id string, unarchive bool) error {
+	src := notePath(zettelHome, id)
+	dest := notePath(filepath.Join(zettelHome, archiveDir), id)
+	verb := "archive"
+	if unarchive {
+		src, dest = dest, src
+		verb = "unarchive"
+	}
+
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		if unarchive {
+			return notFoundf("Archived note does not exist: %v", id)
+		}
+		return notFoundf("Note does not exist: %v", id)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("Note already exists: %v", dest)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("Error creating archive directory: %w", err)
+	}
+	if err := os.Rename(src, dest); err != nil {
+		return fmt.Errorf("Error moving note: %w", err)
+	}
+
+	if unarchive {
+		fmt.Println("Unarchived", id)
+	} else {
+		fmt.Println("Archived", id)
+	}
+	recordChange(zettelHome, verb+" "+id)
+	return nil
+}
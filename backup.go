@@ -0,0 +1,102 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupPath resolves where the archive is written: a timestamped file in
+// dest when dest is a directory (or the working directory when dest is
+// empty), otherwise dest itself.
+func backupPath(dest string) (string, error) {
+	name := defaultHome + "-" + time.Now().Format("20060102150405") + ".tar.gz"
+	if dest == "" {
+		dest = "."
+	}
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		dest = filepath.Join(dest, name)
+	}
+	return filepath.Abs(dest)
+}
+
+// writeBackup archives every file under zettelHome into w, with paths
+// relative to zettelHome, skipping the file at skip.
+func writeBackup(w io.Writer, zettelHome, skip string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(zettelHome, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if abs, _ := filepath.Abs(path); abs == skip {
+			return nil
+		}
+
+		rel, err := filepath.Rel(zettelHome, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func backupVault(zettelHome, dest string) error {
+	path, err := backupPath(dest)
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Error creating archive: %w", err)
+	}
+
+	if err := writeBackup(f, zettelHome, path); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("Error writing archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Error writing archive: %w", err)
+	}
+
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// bundleNotes returns id followed by the notes reachable through its links
+// within depth hops, in breadth-first order, each visited once. unresolved
+// maps each note to the link targets that resolve to no single note.
+func bundleNotes(zettelHome, id string, depth int) (order []string, contents map[string]string, unresolved map[string][]string, err error) {
+	contents = map[string]string{}
+	unresolved = map[string][]string{}
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	level := []string{id}
+	for hop := 0; len(level) > 0; hop++ {
+		var next []string
+		for _, current := range level {
+			if _, seen := contents[current]; seen {
+				continue
+			}
+			content, err := readNote(zettelHome, current)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			contents[current] = content
+			order = append(order, current)
+
+			if hop == depth {
+				continue
+			}
+			for _, target := range parseLinks(content) {
+				resolved, _ := r.resolve(target)
+				if resolved == "" {
+					unresolved[current] = append(unresolved[current], target)
+					continue
+				}
+				next = append(next, resolved)
+			}
+		}
+		level = next
+	}
+
+	return order, contents, unresolved, nil
+}
+
+func writeBundle(w io.Writer, order []string, contents map[string]string, unresolved map[string][]string) error {
+	for i, id := range order {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n---\n\n"); err != nil {
+				return err
+			}
+		}
+		content := strings.TrimRight(contents[id], "\n") + "\n"
+		for _, target := range unresolved[id] {
+			content += fmt.Sprintf("<!-- unresolved link: [[%s]] -->\n", target)
+		}
+		if _, err := io.WriteString(w, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportBundle writes a note and the notes it links to, up to depth hops
+// away, as one markdown document.
+func exportBundle(zettelHome, id, out string, depth int) error {
+	if _, err := os.Stat(notePath(zettelHome, id)); os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	}
+
+	order, contents, unresolved, err := bundleNotes(zettelHome, id, depth)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	w := os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return fmt.Errorf("Error creating file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := writeBundle(w, order, contents, unresolved); err != nil {
+		return fmt.Errorf("Error writing export: %w", err)
+	}
+	return nil
+}
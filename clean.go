@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// stubWords counts the words of a note besides its frontmatter, headings
+// and tags, which a note created and then abandoned still has.
+func stubWords(content string) int {
+	_, body, _ := splitFrontmatter(content)
+	words := 0
+	for _, line := range strings.Split(body, "\n") {
+		if _, ok := parseHeading(line); ok {
+			continue
+		}
+		words += countWords(validTagRegex.ReplaceAllString(line, " "))
+	}
+	return words
+}
+
+// cleanNotes lists the notes with fewer than minWords words of their own,
+// and with remove deletes them after confirmation the way delete does.
+func cleanNotes(zettelHome string, minWords int, remove bool) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	var stubs []string
+	words := map[string]int{}
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		if n := stubWords(content); n < minWords {
+			stubs = append(stubs, id)
+			words[id] = n
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, id := range stubs {
+		fmt.Fprintf(w, "%s\t%d words\n", id+noteExtension, words[id])
+	}
+	w.Flush()
+	if !remove || len(stubs) == 0 {
+		return nil
+	}
+	if !dryRun && !confirm(fmt.Sprintf("Delete %d notes?", len(stubs))) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	links, notes := 0, 0
+	for _, id := range stubs {
+		l, n, err := removeNote(zettelHome, id)
+		if err != nil {
+			return err
+		}
+		links += l
+		notes += n
+	}
+	if dryRun {
+		fmt.Printf("Would delete %d notes, removing %d links from %d notes\n", len(stubs), links, notes)
+		return nil
+	}
+	recordChange(zettelHome, fmt.Sprintf("clean %d notes", len(stubs)))
+	fmt.Printf("Deleted %d notes, removed %d links from %d notes\n", len(stubs), links, notes)
+	return nil
+}
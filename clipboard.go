@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// clipboardCommands lists, per platform, the commands that print the
+// clipboard, in order of preference.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	}
+	commands := [][]string{
+		{"xclip", "-selection", "clipboard", "-o"},
+		{"xsel", "--clipboard", "--output"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append([][]string{{"wl-paste", "--no-newline"}}, commands...)
+	}
+	return commands
+}
+
+// readClipboard returns the text on the system clipboard using the first
+// clipboard tool found.
+func readClipboard() (string, error) {
+	for _, command := range clipboardCommands() {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(command[0], command[1:]...).Output()
+		return string(out), err
+	}
+	if runtime.GOOS == "linux" {
+		return "", errors.New("no clipboard tool found; install wl-clipboard, xclip or xsel")
+	}
+	return "", errors.New("no clipboard tool found")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"regexp"
+)
+
+// noColor is set by the --no-color flag.
+var noColor bool
+
+// stdoutIsTerminal reports whether stdout is a terminal; color output is
+// only used when it is.
+var stdoutIsTerminal = func() bool { return isTerminal(os.Stdout) }
+
+const (
+	colorFile  = "\033[35m"
+	colorMatch = "\033[1;31m"
+	colorTag   = "\033[36m"
+	colorLink  = "\033[4;34m"
+	colorTitle = "\033[1m"
+	colorReset = "\033[0m"
+)
+
+// useColor reports whether output should be colored: stdout is a terminal
+// and neither --no-color nor NO_COLOR asks for plain text.
+func useColor() bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && stdoutIsTerminal()
+}
+
+// colorize wraps s in the ANSI color code when output is colored.
+func colorize(color, s string) string {
+	if s == "" || !useColor() {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// highlightQuery colors every occurrence of query in line.
+func highlightQuery(line, query string, ignoreCase bool) string {
+	if query == "" || !useColor() {
+		return line
+	}
+	pattern := regexp.QuoteMeta(query)
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern).ReplaceAllStringFunc(line, func(match string) string {
+		return colorMatch + match + colorReset
+	})
+}
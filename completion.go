@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// subcommands lists the commands offered by shell completion.
+var subcommands = []string{
+	"help", "version", "where", "shell", "new", "next-id", "import", "today", "edit", "show", "open", "open-id", "list", "recent", "log", "touch", "search", "find",
+	"random", "link", "delete", "rename", "merge", "split", "pin", "unpin", "pinned", "alias", "unalias", "archive", "unarchive", "back", "backlinks", "links", "follow", "progress", "stats", "duplicates", "dedup", "orphans",
+	"index-build", "related", "suggest", "export", "export-obsidian", "render", "publish", "backup", "config", "outline", "index", "watch-index", "reindex", "graph", "tags", "tag",
+	"clean", "doctor", "vault", "completion",
+}
+
+// idCommands are the commands whose arguments are note IDs, tagCommands
+// those whose arguments are tags, and tagFlags the flags that take a tag;
+// completion suggests the vault's IDs and tags for them through the hidden
+// __complete-ids and __complete-tags commands. watch-index takes an ID and
+// then tags, and tag a subcommand from tagSubcommands.
+var (
+	idCommands = []string{
+		"edit", "show", "open-id", "touch", "link", "delete", "rename", "merge", "split", "pin", "unpin", "alias", "archive", "backlinks", "links", "follow",
+		"progress", "related", "suggest", "export", "render", "outline", "reindex",
+	}
+	tagCommands    = []string{"index", "-i", "--index"}
+	tagSubcommands = []string{"rename", "add", "remove"}
+	tagFlags       = []string{"--tag", "--exclude-tag"}
+)
+
+// printCompletionIDs prints the IDs of all notes for shell completion.
+func printCompletionIDs(zettelHome string) {
+	ids, _ := listNoteIDs(zettelHome)
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+}
+
+// printCompletionTags prints every tag in the vault, without the '#', for
+// shell completion.
+func printCompletionTags(zettelHome string) {
+	ids, _ := listNoteIDs(zettelHome)
+	tags, _, _ := collectTags(zettelHome, ids)
+	for _, tag := range tags {
+		fmt.Println(tag)
+	}
+}
+
+// completionOptions returns the option aliases of commands, sorted.
+func completionOptions() []string {
+	options := make([]string, 0, len(commandAliases))
+	for alias := range commandAliases {
+		options = append(options, alias)
+	}
+	sort.Strings(options)
+	return options
+}
+
+func bashCompletion() string {
+	words := strings.Join(append(append([]string{}, subcommands...), completionOptions()...), " ")
+	return `_zettel() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "` + words + `" -- "$cur"))
+        return
+    fi
+    case "$prev" in
+        ` + strings.Join(tagFlags, "|") + `)
+            COMPREPLY=($(compgen -W "$(zettel __complete-tags 2>/dev/null)" -- "$cur"))
+            return ;;
+    esac
+    case "${COMP_WORDS[1]}" in
+        ` + strings.Join(idCommands, "|") + `)
+            COMPREPLY=($(compgen -W "$(zettel __complete-ids 2>/dev/null)" -- "$cur")) ;;
+        ` + strings.Join(tagCommands, "|") + `)
+            COMPREPLY=($(compgen -W "$(zettel __complete-tags 2>/dev/null)" -- "$cur")) ;;
+        watch-index)
+            if [ "$COMP_CWORD" -eq 2 ]; then
+                COMPREPLY=($(compgen -W "$(zettel __complete-ids 2>/dev/null)" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -W "$(zettel __complete-tags 2>/dev/null)" -- "$cur"))
+            fi ;;
+        tag)
+            case "$COMP_CWORD:${COMP_WORDS[2]}" in
+                2:*)
+                    COMPREPLY=($(compgen -W "` + strings.Join(tagSubcommands, " ") + `" -- "$cur")) ;;
+                3:add|3:remove)
+                    COMPREPLY=($(compgen -W "$(zettel __complete-ids 2>/dev/null)" -- "$cur")) ;;
+                4:add|4:remove)
+                    COMPREPLY=($(compgen -W "$(zettel __complete-tags 2>/dev/null)" -- "$cur")) ;;
+                *:rename)
+                    if [ "$prev" = --note ]; then
+                        COMPREPLY=($(compgen -W "$(zettel __complete-ids 2>/dev/null)" -- "$cur"))
+                    else
+                        COMPREPLY=($(compgen -W "$(zettel __complete-tags 2>/dev/null)" -- "$cur"))
+                    fi ;;
+            esac ;;
+    esac
+}
+complete -o default -F _zettel zettel
+`
+}
+
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString("#compdef zettel\n\n_zettel() {\n    local -a commands\n    commands=(\n")
+	for _, command := range subcommands {
+		fmt.Fprintf(&b, "        '%s'\n", command)
+	}
+	b.WriteString("    )\n    _arguments \\\n")
+	for _, option := range completionOptions() {
+		if slices.Contains(tagCommands, option) {
+			fmt.Fprintf(&b, "        '(- *)%s[%s]:*:tag:->tags' \\\n", option, commandAliases[option])
+		} else {
+			fmt.Fprintf(&b, "        '(- *)%s[%s]' \\\n", option, commandAliases[option])
+		}
+	}
+	b.WriteString("        '1: :->command' \\\n        '*:: :->args'\n\n")
+	b.WriteString("    case $state in\n    command)\n        compadd -a commands ;;\n")
+	b.WriteString("    tags)\n        compadd -- ${(f)\"$(zettel __complete-tags 2>/dev/null)\"} ;;\n    args)\n")
+	fmt.Fprintf(&b, "        if [[ %s ]]; then\n", zshAnyOf("${words[CURRENT-1]}", tagFlags))
+	b.WriteString("            compadd -- ${(f)\"$(zettel __complete-tags 2>/dev/null)\"}\n            return\n        fi\n")
+	b.WriteString("        case ${words[1]} in\n")
+	fmt.Fprintf(&b, "        %s)\n            compadd -- ${(f)\"$(zettel __complete-ids 2>/dev/null)\"} ;;\n", strings.Join(idCommands, "|"))
+	fmt.Fprintf(&b, "        %s)\n            compadd -- ${(f)\"$(zettel __complete-tags 2>/dev/null)\"} ;;\n", strings.Join(tagCommands, "|"))
+	b.WriteString("        watch-index)\n            if (( CURRENT == 2 )); then\n")
+	b.WriteString("                compadd -- ${(f)\"$(zettel __complete-ids 2>/dev/null)\"}\n            else\n")
+	b.WriteString("                compadd -- ${(f)\"$(zettel __complete-tags 2>/dev/null)\"}\n            fi ;;\n")
+	b.WriteString("        tag)\n            case $CURRENT:${words[2]} in\n")
+	fmt.Fprintf(&b, "            2:*)\n                compadd %s ;;\n", strings.Join(tagSubcommands, " "))
+	b.WriteString("            3:add|3:remove)\n                compadd -- ${(f)\"$(zettel __complete-ids 2>/dev/null)\"} ;;\n")
+	b.WriteString("            4:add|4:remove)\n                compadd -- ${(f)\"$(zettel __complete-tags 2>/dev/null)\"} ;;\n")
+	b.WriteString("            *:rename)\n                if [[ ${words[CURRENT-1]} == --note ]]; then\n")
+	b.WriteString("                    compadd -- ${(f)\"$(zettel __complete-ids 2>/dev/null)\"}\n                else\n")
+	b.WriteString("                    compadd -- ${(f)\"$(zettel __complete-tags 2>/dev/null)\"}\n                fi ;;\n")
+	b.WriteString("            esac ;;\n")
+	b.WriteString("        *)\n            _files ;;\n        esac ;;\n    esac\n}\n\n_zettel \"$@\"\n")
+	return b.String()
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString("complete -c zettel -f\n")
+	for _, command := range subcommands {
+		fmt.Fprintf(&b, "complete -c zettel -n '__fish_use_subcommand' -a %s\n", command)
+	}
+	for _, option := range completionOptions() {
+		if strings.HasPrefix(option, "--") {
+			fmt.Fprintf(&b, "complete -c zettel -n '__fish_use_subcommand' -l %s -d %s\n", option[2:], commandAliases[option])
+		} else {
+			fmt.Fprintf(&b, "complete -c zettel -n '__fish_use_subcommand' -s %s -d %s\n", option[1:], commandAliases[option])
+		}
+	}
+	const (
+		ids  = "-a '(zettel __complete-ids 2>/dev/null)'"
+		tags = "-a '(zettel __complete-tags 2>/dev/null)'"
+		argc = "test (count (commandline -opc))"
+	)
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from %s' %s\n", strings.Join(idCommands, " "), ids)
+	// search -i is not the -i alias of index, so only the first word counts.
+	fmt.Fprintf(&b, "complete -c zettel -n 'contains -- (commandline -opc)[2] %s' %s\n", strings.Join(tagCommands, " "), tags)
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from watch-index; and %s -eq 2' %s\n", argc, ids)
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from watch-index; and %s -ge 3' %s\n", argc, tags)
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from tag; and %s -eq 2' -a '%s'\n", argc, strings.Join(tagSubcommands, " "))
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from tag; and __fish_seen_subcommand_from add remove; and %s -eq 3' %s\n", argc, ids)
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from tag; and __fish_seen_subcommand_from add remove; and %s -eq 4' %s\n", argc, tags)
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from tag; and __fish_seen_subcommand_from rename; and not __fish_prev_arg_in --note' %s\n", tags)
+	fmt.Fprintf(&b, "complete -c zettel -n '__fish_seen_subcommand_from tag; and __fish_seen_subcommand_from rename; and __fish_prev_arg_in --note' %s\n", ids)
+	for _, flag := range tagFlags {
+		fmt.Fprintf(&b, "complete -c zettel -l %s -x %s\n", flag[2:], tags)
+	}
+	return b.String()
+}
+
+// zshAnyOf returns a zsh condition testing whether word is one of values.
+func zshAnyOf(word string, values []string) string {
+	conds := make([]string, len(values))
+	for i, value := range values {
+		conds[i] = word + " == " + value
+	}
+	return strings.Join(conds, " || ")
+}
+
+func printCompletion(shell string) error {
+	switch shell {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		fmt.Print(zshCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	default:
+		return usagef("Unsupported shell: %v", shell)
+	}
+	return nil
+}
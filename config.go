@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const configFileName = "config.toml"
+
+// config holds the settings read from the config file. Environment variables
+// take precedence over it, and it takes precedence over built-in defaults.
+type config struct {
+	Dir             string
+	Editor          string
+	Template        string
+	PlaceholderTag  string
+	ExcerptLength   int
+	HidePlaceholder bool
+	AutoCommit      bool
+	// IDFormat is the Go time layout new note IDs start with.
+	IDFormat string
+	// NoteExtension is the extension of note files, with its dot.
+	NoteExtension string
+	// Vaults maps vault names to directories, from "vault.<name>" keys.
+	Vaults map[string]string
+}
+
+// cfg is the configuration loaded at startup.
+var cfg = defaultConfig()
+
+func defaultConfig() config {
+	return config{
+		Dir:            filepath.Join("~", defaultHome),
+		PlaceholderTag: defaultPlaceholderTag,
+		ExcerptLength:  200,
+		IDFormat:       defaultIDFormat,
+		NoteExtension:  defaultNoteExtension,
+	}
+}
+
+type configKey struct {
+	name string
+	doc  string
+	// set parses value into c, reporting type mismatches and invalid values.
+	set func(c *config, value string) error
+	// get formats the key's value in c.
+	get func(c config) string
+	// check optionally validates the value against the environment; it
+	// only runs for "zettel config check".
+	check func(c config) error
+}
+
+var configKeys = []configKey{
+	{
+		name: "dir",
+		doc:  "Notes directory, overridden by ZETTEL_HOME",
+		set: func(c *config, value string) error {
+			if value == "" {
+				return errors.New("dir is empty")
+			}
+			c.Dir = value
+			return nil
+		},
+		get: func(c config) string { return strconv.Quote(c.Dir) },
+		check: func(c config) error {
+			dir, err := expandHome(c.Dir)
+			if err != nil {
+				return err
+			}
+			return checkWritableDir(dir)
+		},
+	},
+	{
+		name: "editor",
+		doc:  "Editor command with any arguments, overridden by EDITOR (default: nano)",
+		set: func(c *config, value string) error {
+			c.Editor = value
+			return nil
+		},
+		get: func(c config) string { return strconv.Quote(c.Editor) },
+		check: func(c config) error {
+			fields, err := splitCommand(c.Editor)
+			if err != nil {
+				return err
+			}
+			if len(fields) == 0 {
+				return nil
+			}
+			if _, err := exec.LookPath(fields[0]); err != nil {
+				return fmt.Errorf("editor %q not found", fields[0])
+			}
+			return nil
+		},
+	},
+	{
+		name: "template",
+		doc:  "Template file for new notes, like those of new --template; empty uses the built-in body",
+		set: func(c *config, value string) error {
+			c.Template = value
+			return nil
+		},
+		get: func(c config) string { return strconv.Quote(c.Template) },
+		check: func(c config) error {
+			if c.Template == "" {
+				return nil
+			}
+			path, err := expandHome(c.Template)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stat(path)
+			return err
+		},
+	},
+	{
+		name: "placeholder_tag",
+		doc:  "Tag seeded into new notes, overridden by ZETTEL_PLACEHOLDER_TAG",
+		set: func(c *config, value string) error {
+			tag := strings.TrimPrefix(value, "#")
+			if !isTagName(tag) {
+				return fmt.Errorf("%q is not a valid tag", value)
+			}
+			c.PlaceholderTag = tag
+			return nil
+		},
+		get: func(c config) string { return strconv.Quote(c.PlaceholderTag) },
+	},
+	{
+		name: "excerpt_length",
+		doc:  "Default excerpt length for export",
+		set: func(c *config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("expected an integer, got %q", value)
+			}
+			if n <= 0 {
+				return fmt.Errorf("must be positive, got %d", n)
+			}
+			c.ExcerptLength = n
+			return nil
+		},
+		get: func(c config) string { return strconv.Itoa(c.ExcerptLength) },
+	},
+	{
+		name: "hide_placeholder",
+		doc:  "Omit the placeholder tag from tags by default",
+		set: func(c *config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			c.HidePlaceholder = b
+			return nil
+		},
+		get: func(c config) string { return strconv.FormatBool(c.HidePlaceholder) },
+	},
+	{
+		name: "auto_commit",
+		doc:  "Commit changes to the notes directory in git after mutating commands",
+		set: func(c *config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			c.AutoCommit = b
+			return nil
+		},
+		get: func(c config) string { return strconv.FormatBool(c.AutoCommit) },
+	},
+	{
+		name: "id_format",
+		doc:  "Go time layout of the timestamp new note IDs start with, such as 20060102150405 for seconds (default: 200601021504, minutes)",
+		set: func(c *config, value string) error {
+			if err := checkIDFormat(value); err != nil {
+				return err
+			}
+			c.IDFormat = value
+			return nil
+		},
+		get: func(c config) string { return strconv.Quote(c.IDFormat) },
+	},
+	{
+		name: "note_extension",
+		doc:  "Extension of note files, such as .markdown or .txt",
+		set: func(c *config, value string) error {
+			ext := "." + strings.TrimPrefix(value, ".")
+			if ext == "." || filepath.Ext(ext) != ext || strings.ContainsAny(ext, `/\:*?"<>|[]#`) || strings.ContainsFunc(ext, unicode.IsSpace) {
+				return fmt.Errorf("%q is not a file extension", value)
+			}
+			c.NoteExtension = ext
+			return nil
+		},
+		get: func(c config) string { return strconv.Quote(c.NoteExtension) },
+	},
+}
+
+// checkIDFormat reports whether layout makes IDs that are safe filenames and
+// change over time.
+func checkIDFormat(layout string) error {
+	t := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	id := t.Format(layout)
+	if id == "" || strings.ContainsAny(id, `/\:*?"<>|[]#`) || strings.ContainsFunc(id, unicode.IsSpace) {
+		return fmt.Errorf("%q makes IDs such as %q that are not safe filenames", layout, id)
+	}
+	if t.AddDate(0, 0, 1).Format(layout) == id {
+		return fmt.Errorf("%q does not include the date", layout)
+	}
+	return nil
+}
+
+// configPath returns zettel/config.toml in os.UserConfigDir: on Linux
+// $XDG_CONFIG_HOME or ~/.config, on macOS ~/Library/Application Support.
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "zettel", configFileName), nil
+}
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// checkWritableDir reports whether notes can be written to dir, or to its
+// parent when dir does not exist yet.
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		parent := filepath.Dir(dir)
+		if _, err := os.Stat(parent); err != nil {
+			return fmt.Errorf("parent directory %s does not exist", parent)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".zettel-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable", dir)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// parseConfig reads "key = value" lines, a subset of TOML, skipping blank
+// lines and comments. Every invalid line is reported with its number.
+func parseConfig(r io.Reader) (config, error) {
+	c := defaultConfig()
+	var errs []error
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("line %d: expected key = value", lineNo))
+			continue
+		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		if vault, ok := strings.CutPrefix(name, vaultKeyPrefix); ok {
+			if err := setVault(&c, vault, value); err != nil {
+				errs = append(errs, fmt.Errorf("line %d: %s: %w", lineNo, name, err))
+			}
+			continue
+		}
+
+		var key *configKey
+		for i := range configKeys {
+			if configKeys[i].name == name {
+				key = &configKeys[i]
+			}
+		}
+		if key == nil {
+			errs = append(errs, fmt.Errorf("line %d: unknown key %q", lineNo, name))
+			continue
+		}
+		if err := key.set(&c, value); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %s: %w", lineNo, name, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return c, errors.Join(errs...)
+}
+
+// loadConfig reads the config file at path, returning the defaults when it
+// does not exist.
+func loadConfig(path string) (config, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return defaultConfig(), nil
+	} else if err != nil {
+		return defaultConfig(), err
+	}
+	defer f.Close()
+
+	return parseConfig(f)
+}
+
+func printDefaultConfig() {
+	c := defaultConfig()
+	for i, key := range configKeys {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println("# " + key.doc)
+		fmt.Printf("%s = %s\n", key.name, key.get(c))
+	}
+	fmt.Println()
+	fmt.Println(`# Vaults selected with --vault, as added by "zettel vault add"`)
+	fmt.Println(`# vault.work = "~/work-notes"`)
+}
+
+func checkConfig(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println("No config file at", path)
+		return nil
+	}
+
+	c, err := loadConfig(path)
+	errs := []error{err}
+	for _, key := range configKeys {
+		if key.check == nil {
+			continue
+		}
+		if err := key.check(c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key.name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s:\n%s", path, err)
+	}
+	fmt.Println(path + ": ok")
+	return nil
+}
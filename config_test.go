@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	c, err := parseConfig(strings.NewReader(`# notes
+dir = "~/notes"
+editor = "code --wait"
+
+excerpt_length = 80
+hide_placeholder = true
+placeholder_tag = "#inbox"
+note_extension = "txt"
+vault.work = "~/work"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Dir != "~/notes" || c.Editor != "code --wait" || c.ExcerptLength != 80 || !c.HidePlaceholder ||
+		c.PlaceholderTag != "inbox" || c.NoteExtension != ".txt" || c.Vaults["work"] != "~/work" {
+		t.Errorf("parseConfig = %+v", c)
+	}
+	if c.IDFormat != defaultConfig().IDFormat {
+		t.Errorf("unset id_format = %q, want the default", c.IDFormat)
+	}
+
+	tests := []struct {
+		name, line, want string
+	}{
+		{"unknown key", "colour = red", `line 1: unknown key "colour"`},
+		{"not key value", "dir", "line 1: expected key = value"},
+		{"not an integer", "excerpt_length = long", `line 1: excerpt_length: expected an integer, got "long"`},
+		{"not positive", "excerpt_length = 0", "line 1: excerpt_length: must be positive, got 0"},
+		{"not a bool", "auto_commit = yes", `line 1: auto_commit: expected true or false, got "yes"`},
+		{"bad tag", `placeholder_tag = "to do"`, `line 1: placeholder_tag: "to do" is not a valid tag`},
+		{"bad id format", `id_format = "Jan 2"`, "line 1: id_format:"},
+		{"bad vault name", `vault.a/b = "~/x"`, `line 1: vault.a/b: "a/b" is not a valid vault name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConfig(strings.NewReader(tt.line))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("parseConfig(%q) error = %v, want %q", tt.line, err, tt.want)
+			}
+		})
+	}
+
+	_, err = parseConfig(strings.NewReader("colour = red\n\nexcerpt_length = x\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 1:") || !strings.Contains(err.Error(), "line 3:") {
+		t.Errorf("every invalid line should be reported, got %v", err)
+	}
+}
+
+func TestResolveZettelHome(t *testing.T) {
+	home, configHome := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", "")
+	defer func(c config) { cfg, dirOverride, vaultName = c, "", "" }(cfg)
+
+	tests := []struct {
+		name                       string
+		dir, vault, env, configDir string
+		wantDir, wantSource        string
+	}{
+		{"default", "", "", "", "", filepath.Join(home, "zettelkasten"), "default"},
+		{"config file", "", "", "", "~/notes", filepath.Join(home, "notes"), filepath.Join(configHome, "zettel", configFileName)},
+		{"ZETTEL_HOME", "", "", "/env", "~/notes", "/env", "ZETTEL_HOME"},
+		{"--vault", "", "work", "/env", "~/notes", filepath.Join(configHome, "zettel", "vaults", "work"), "--vault work"},
+		{"--dir", "~/flag", "work", "/env", "~/notes", filepath.Join(home, "flag"), "--dir flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirOverride, vaultName = tt.dir, tt.vault
+			t.Setenv("ZETTEL_HOME", tt.env)
+			cfg = defaultConfig()
+			if tt.configDir != "" {
+				cfg.Dir = tt.configDir
+			}
+
+			dir, source, err := resolveZettelHome()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dir != tt.wantDir || source != tt.wantSource {
+				t.Errorf("resolveZettelHome() = %q, %q; want %q, %q", dir, source, tt.wantDir, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestDefaultZettelHome(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_DATA_HOME is only used on Linux")
+	}
+	home, data := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name, xdgDataHome string
+		existing          bool
+		want              string
+	}{
+		{"unset", "", false, filepath.Join(home, "zettelkasten")},
+		{"relative", "data", false, filepath.Join(home, "zettelkasten")},
+		{"XDG_DATA_HOME", data, false, filepath.Join(data, "zettelkasten")},
+		{"existing notes", data, true, filepath.Join(home, "zettelkasten")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_DATA_HOME", tt.xdgDataHome)
+			if tt.existing {
+				if err := os.Mkdir(filepath.Join(home, "zettelkasten"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, err := defaultZettelHome(); err != nil || got != tt.want {
+				t.Errorf("defaultZettelHome() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct {
+		path, want string
+	}{
+		{"~", home},
+		{"~/notes", filepath.Join(home, "notes")},
+		{"~" + string(filepath.Separator) + "notes", filepath.Join(home, "notes")},
+		{"~user/notes", "~user/notes"},
+		{"/srv/notes", "/srv/notes"},
+		{"notes/~", "notes/~"},
+	}
+	for _, tt := range tests {
+		if got, err := expandHome(tt.path); err != nil || got != tt.want {
+			t.Errorf("expandHome(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// deleteNote removes a note and strips the links other notes had to it.
+func deleteNote(zettelHome, id string, force bool) error {
+	notePath := filepath.Join(zettelHome, id+noteExtension)
+	if _, err := os.Stat(notePath); os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	}
+
+	if !force && !dryRun && !confirm(fmt.Sprintf("Delete note %s?", id)) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	links, notes, err := removeNote(zettelHome, id)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Printf("Would delete %s, removing %d links from %d notes\n", id, links, notes)
+		return nil
+	}
+	recordChange(zettelHome, "delete "+id)
+	fmt.Printf("Deleted %s, removed %d links from %d notes\n", id, links, notes)
+	return nil
+}
+
+// removeNote deletes the note id, drops its aliases and strips the links
+// other notes have to it by ID, slug, alias or title. It returns the number of links removed and of
+// notes they were removed from.
+func removeNote(zettelHome, id string) (links, notes int, err error) {
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	path := filepath.Join(zettelHome, id+noteExtension)
+	if err := stageRemove(zettelHome, path); err != nil {
+		return 0, 0, fmt.Errorf("Error deleting note: %w", err)
+	}
+
+	if err := retargetAliases(zettelHome, id, ""); err != nil {
+		return 0, 0, fmt.Errorf("Error updating aliases: %w", err)
+	}
+
+	for _, other := range sortedKeys(r.contents) {
+		if other == id {
+			continue
+		}
+		updated, n := removeLinks(r.contents[other], r, id)
+		if n == 0 {
+			continue
+		}
+		if err := stageWrite(zettelHome, filepath.Join(zettelHome, other+noteExtension), []byte(updated), fmt.Sprintf("remove %d links", n)); err != nil {
+			return 0, 0, fmt.Errorf("Error writing note: %w", err)
+		}
+		links += n
+		notes++
+	}
+
+	return links, notes, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// dryRun is set by the --dry-run flag. The commands in dryRunCommands then
+// print each file they would write, rename or delete, one per line in a
+// stable order, instead of touching the notes directory.
+var dryRun bool
+
+var dryRunCommands = []string{"link", "delete", "rename", "merge", "tag", "search", "clean", "dedup", "doctor"}
+
+// checkDryRun returns a usage error if --dry-run was given to a command that
+// would ignore it.
+func checkDryRun(command string) error {
+	if name, _, _ := strings.Cut(command, " "); dryRun && !slices.Contains(dryRunCommands, name) {
+		return usagef("--dry-run is not supported by %s", command)
+	}
+	return nil
+}
+
+func displayPath(zettelHome, path string) string {
+	if rel, err := filepath.Rel(zettelHome, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// stageWrite atomically writes data to path, or under --dry-run prints the
+// write with the reason for it.
+func stageWrite(zettelHome, path string, data []byte, reason string) error {
+	if dryRun {
+		fmt.Printf("write %s: %s\n", displayPath(zettelHome, path), reason)
+		return nil
+	}
+	return writeFileAtomic(path, data)
+}
+
+// stageRename renames oldPath to newPath, or under --dry-run prints the
+// rename.
+func stageRename(zettelHome, oldPath, newPath string) error {
+	if dryRun {
+		fmt.Printf("rename %s -> %s\n", displayPath(zettelHome, oldPath), displayPath(zettelHome, newPath))
+		return nil
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// stageRemove deletes path, or under --dry-run prints the deletion.
+func stageRemove(zettelHome, path string) error {
+	if dryRun {
+		fmt.Printf("delete %s\n", displayPath(zettelHome, path))
+		return nil
+	}
+	return os.Remove(path)
+}
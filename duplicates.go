@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// findDuplicates reports the titles, compared case-insensitively, that more
+// than one note shares.
+func findDuplicates(zettelHome string) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	titles := map[string]string{}
+	groups := map[string][]string{}
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		title := titleOrSlug(content, id)
+		if title == "" {
+			continue
+		}
+		key := strings.ToLower(title)
+		if _, ok := titles[key]; !ok {
+			titles[key] = title
+		}
+		groups[key] = append(groups[key], id+noteExtension)
+	}
+
+	var keys []string
+	for key, files := range groups {
+		if len(files) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if jsonOutput {
+		type duplicate struct {
+			Title     string   `json:"title"`
+			Filenames []string `json:"filenames"`
+		}
+		results := []duplicate{}
+		for _, key := range keys {
+			results = append(results, duplicate{titles[key], groups[key]})
+		}
+		return printJSON(results)
+	}
+
+	if len(keys) == 0 {
+		fmt.Println("No duplicate titles")
+		return nil
+	}
+	for i, key := range keys {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(titles[key])
+		for _, file := range groups[key] {
+			fmt.Println("  " + file)
+		}
+	}
+	return nil
+}
+
+// contentDuplicates groups the notes whose bodies, without frontmatter, are
+// identical and not empty, by the hex sha256 of the body. Each group lists
+// its notes oldest first.
+func contentDuplicates(zettelHome string) (map[string][]string, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]string{}
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return nil, err
+		}
+		_, body, _ := splitFrontmatter(content)
+		if strings.TrimSpace(body) == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(body))
+		hash := hex.EncodeToString(sum[:])
+		groups[hash] = append(groups[hash], id)
+	}
+	for hash, group := range groups {
+		if len(group) < 2 {
+			delete(groups, hash)
+			continue
+		}
+		sort.Strings(group)
+	}
+	return groups, nil
+}
+
+// dedupNotes reports the notes with identical bodies under the first
+// characters of their hash. With deleteDupes it keeps the oldest note of
+// each group, points the links to the others at it and deletes them.
+func dedupNotes(zettelHome string, deleteDupes bool) error {
+	groups, err := contentDuplicates(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+	hashes := sortedKeys(groups)
+
+	if jsonOutput {
+		type duplicate struct {
+			Hash      string   `json:"hash"`
+			Filenames []string `json:"filenames"`
+		}
+		results := []duplicate{}
+		for _, hash := range hashes {
+			var files []string
+			for _, id := range groups[hash] {
+				files = append(files, id+noteExtension)
+			}
+			results = append(results, duplicate{hash, files})
+		}
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else if len(hashes) == 0 {
+		fmt.Println("No duplicate notes")
+	} else {
+		for i, hash := range hashes {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(hash[:12])
+			for _, id := range groups[hash] {
+				fmt.Println("  " + id + noteExtension)
+			}
+		}
+	}
+
+	if !deleteDupes || len(hashes) == 0 {
+		return nil
+	}
+	dupes := 0
+	for _, hash := range hashes {
+		dupes += len(groups[hash]) - 1
+	}
+	if !dryRun && !confirm(fmt.Sprintf("Delete %d duplicates, keeping the oldest of each group?", dupes)) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	for _, hash := range hashes {
+		keep := groups[hash][0]
+		for _, id := range groups[hash][1:] {
+			links, notes, err := relinkNotes(zettelHome, id, keep)
+			if err != nil {
+				return fmt.Errorf("Error updating links: %w", err)
+			}
+			if err := stageRemove(zettelHome, notePath(zettelHome, id)); err != nil {
+				return fmt.Errorf("Error deleting note: %w", err)
+			}
+			if err := retargetAliases(zettelHome, id, keep); err != nil {
+				return fmt.Errorf("Error updating aliases: %w", err)
+			}
+			if !dryRun {
+				fmt.Printf("Deleted %s, pointed %d links in %d notes at %s\n", id, links, notes, keep)
+			}
+		}
+	}
+	if !dryRun {
+		recordChange(zettelHome, fmt.Sprintf("dedup %d notes", dupes))
+	}
+	return nil
+}
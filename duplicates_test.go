@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContentDuplicates(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"20240301120000-copy":  "# Idea\n\nSame body\n",
+		"20240101120000-idea":  "# Idea\n\nSame body\n",
+		"20240201120000-meta":  "---\ntags: [x]\n---\n# Idea\n\nSame body\n",
+		"20240101120000-other": "# Idea\n\nSame body \n",
+		"20240101120000-a":     "---\ntitle: A\n---\n",
+		"20240102120000-b":     "---\ntitle: B\n---\n\n",
+		"20240101120000-pair":  "# Pair\n",
+		"20240102120000-pair":  "# Pair\n",
+		"archive/20230101-old": "# Idea\n\nSame body\n",
+	})
+
+	groups, err := contentDuplicates(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for _, hash := range sortedKeys(groups) {
+		got = append(got, groups[hash])
+	}
+	want := [][]string{
+		{"20240101120000-idea", "20240201120000-meta", "20240301120000-copy"},
+		{"20240101120000-pair", "20240102120000-pair"},
+	}
+	if len(got) == 2 && got[0][0] != want[0][0] {
+		got[0], got[1] = got[1], got[0]
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("contentDuplicates = %q, want %q", got, want)
+	}
+}
+
+func TestDedupDeleteDupes(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"20240101120000-idea": "# Idea\n",
+		"20240201120000-copy": "# Idea\n",
+		"20240301120000-ref":  "# Ref\n\nsee [[20240201120000-copy]]\n",
+	})
+
+	runZettel(t, home, "--dry-run", "dedup", "--delete-dupes")
+	if _, code := runZettel(t, home, "show", "20240201120000-copy"); code != 0 {
+		t.Fatal("dedup --delete-dupes deleted a note under --dry-run")
+	}
+
+	if _, code := runZettel(t, home, "--yes", "dedup", "--delete-dupes"); code != 0 {
+		t.Fatalf("dedup --delete-dupes exited %d", code)
+	}
+	if _, code := runZettel(t, home, "show", "20240201120000-copy"); code != exitNotFound {
+		t.Error("the newer duplicate was kept")
+	}
+	if got, want := readTestNote(t, home, "20240301120000-ref"), "# Ref\n\nsee [[20240101120000-idea]]\n"; got != want {
+		t.Errorf("ref = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	markdownLinkRegex  = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownAliasRegex = regexp.MustCompile(`\[\[(?:[^\]|]*\|)?([^\]]*)\]\]`)
+	markdownMarkRegex  = regexp.MustCompile("(?m)^\\s{0,3}(#{1,6}\\s+|>\\s?|[-*+]\\s+|\\d+\\.\\s+)|[*_`~]+")
+)
+
+// stripMarkdown reduces a note body to plain text for feed descriptions.
+func stripMarkdown(content string) string {
+	_, body := parseFrontmatter(content)
+	body = markdownLinkRegex.ReplaceAllString(body, "$1")
+	body = markdownAliasRegex.ReplaceAllString(body, "$1")
+	body = markdownMarkRegex.ReplaceAllString(body, "")
+	return strings.Join(strings.Fields(body), " ")
+}
+
+// excerpt shortens text to at most length runes, ending on a word boundary.
+func excerpt(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	cut := string(runes[:length])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+type feedItem struct {
+	id          string
+	title       string
+	created     time.Time
+	description string
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		Description string    `xml:"description"`
+		Items       []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary"`
+}
+
+// recentFeedItems returns the limit most recently created notes, newest
+// first. Notes whose ID carries no timestamp are left out.
+func recentFeedItems(zettelHome string, limit, excerptLength int) ([]feedItem, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []feedItem
+	for _, id := range ids {
+		created, ok := noteCreated(id)
+		if !ok {
+			continue
+		}
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return nil, err
+		}
+		title := noteTitle(content, id)
+		body := strings.Replace(content, "# "+title+"\n", "", 1)
+		items = append(items, feedItem{
+			id:          id,
+			title:       stripMarkdown(title),
+			created:     created,
+			description: excerpt(stripMarkdown(body), excerptLength),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].created.After(items[j].created) })
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+func writeFeed(w io.Writer, format, title, baseURL string, items []feedItem) error {
+	link := func(id string) string { return baseURL + id + ".html" }
+
+	var feed any
+	switch format {
+	case "rss":
+		rss := rssFeed{Version: "2.0"}
+		rss.Channel.Title = title
+		rss.Channel.Link = baseURL
+		rss.Channel.Description = "Recent notes"
+		for _, item := range items {
+			rss.Channel.Items = append(rss.Channel.Items, rssItem{
+				Title:       item.title,
+				Link:        link(item.id),
+				GUID:        link(item.id),
+				PubDate:     item.created.Format(time.RFC1123Z),
+				Description: item.description,
+			})
+		}
+		feed = rss
+	case "atom":
+		atom := atomFeed{Title: title, ID: baseURL, Link: atomLink{Href: baseURL}}
+		if len(items) > 0 {
+			atom.Updated = items[0].created.Format(time.RFC3339)
+		} else {
+			atom.Updated = time.Now().Format(time.RFC3339)
+		}
+		for _, item := range items {
+			atom.Entries = append(atom.Entries, atomEntry{
+				Title:   item.title,
+				ID:      link(item.id),
+				Link:    atomLink{Href: link(item.id)},
+				Updated: item.created.Format(time.RFC3339),
+				Summary: item.description,
+			})
+		}
+		feed = atom
+	default:
+		return fmt.Errorf("unknown feed format %q", format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func exportFeed(zettelHome, format, out, title, baseURL string, limit, excerptLength int) error {
+	items, err := recentFeedItems(zettelHome, limit, excerptLength)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	w := os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return fmt.Errorf("Error creating feed: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := writeFeed(w, format, title, baseURL, items); err != nil {
+		return fmt.Errorf("Error writing feed: %w", err)
+	}
+	return nil
+}
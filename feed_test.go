@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+var feedTestNotes = map[string]string{
+	"20240101090000-first":  "# First\n\nThe **first** note, linking [[20240301090000-third|the third]].\n",
+	"20240301090000-third":  "# Third\n\n- a list\n- of things\n",
+	"20240201090000-second": "# Second\n\nA [link](https://example.com) in the second note.\n",
+	"undated":               "# Undated\n",
+}
+
+func TestRecentFeedItems(t *testing.T) {
+	items, err := recentFeedItems(testVault(t, feedTestNotes), 0, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ id, description string }{
+		{"20240301090000-third", "a list of things"},
+		{"20240201090000-second", "A link in the…"},
+		{"20240101090000-first", "The first note,…"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		if items[i].id != w.id || items[i].description != w.description {
+			t.Errorf("item %d = %s %q, want %s %q", i, items[i].id, items[i].description, w.id, w.description)
+		}
+	}
+
+	if items, _ = recentFeedItems(testVault(t, feedTestNotes), 2, 20); len(items) != 2 {
+		t.Errorf("limit 2 gave %d items", len(items))
+	}
+}
+
+func TestWriteFeed(t *testing.T) {
+	items, err := recentFeedItems(testVault(t, feedTestNotes), 0, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rss bytes.Buffer
+	if err := writeFeed(&rss, "rss", "Notes", "https://example.com/", items); err != nil {
+		t.Fatal(err)
+	}
+	var gotRSS rssFeed
+	if err := xml.Unmarshal(rss.Bytes(), &gotRSS); err != nil {
+		t.Fatalf("invalid RSS: %v\n%s", err, rss.String())
+	}
+	if gotRSS.Version != "2.0" || gotRSS.Channel.Title != "Notes" || len(gotRSS.Channel.Items) != 3 {
+		t.Fatalf("unexpected RSS feed:\n%s", rss.String())
+	}
+	for i, title := range []string{"Third", "Second", "First"} {
+		if item := gotRSS.Channel.Items[i]; item.Title != title {
+			t.Errorf("RSS item %d is %q, want %q", i, item.Title, title)
+		}
+	}
+	if link := gotRSS.Channel.Items[0].Link; link != "https://example.com/20240301090000-third.html" {
+		t.Errorf("RSS link = %q", link)
+	}
+
+	var atom bytes.Buffer
+	if err := writeFeed(&atom, "atom", "Notes", "https://example.com/", items); err != nil {
+		t.Fatal(err)
+	}
+	var gotAtom atomFeed
+	if err := xml.Unmarshal(atom.Bytes(), &gotAtom); err != nil {
+		t.Fatalf("invalid Atom: %v\n%s", err, atom.String())
+	}
+	if len(gotAtom.Entries) != 3 || gotAtom.Entries[0].Title != "Third" || gotAtom.Updated != gotAtom.Entries[0].Updated {
+		t.Errorf("unexpected Atom feed:\n%s", atom.String())
+	}
+
+	if err := writeFeed(&atom, "json", "Notes", "", items); err == nil {
+		t.Error("writeFeed accepted an unknown format")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const findExcerptLength = 80
+
+var recordEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// formatRecord joins fields with tabs, escaping backslashes, tabs and line
+// breaks inside fields so that every record is exactly one line.
+func formatRecord(fields ...string) string {
+	escaped := make([]string, len(fields))
+	for i, field := range fields {
+		escaped[i] = recordEscaper.Replace(field)
+	}
+	return strings.Join(escaped, "\t")
+}
+
+// matchExcerpt returns the first line of content containing query, falling
+// back to the first non-heading line of the body.
+func matchExcerpt(content, query string, ignoreCase bool) string {
+	_, body := parseFrontmatter(content)
+	lines := strings.Split(body, "\n")
+	for _, line := range lines {
+		if query != "" && containsQuery(line, query, ignoreCase) {
+			return excerpt(strings.TrimSpace(line), findExcerptLength)
+		}
+	}
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "# ") {
+			return excerpt(line, findExcerptLength)
+		}
+	}
+	return ""
+}
+
+// findNotes prints one record per note whose ID or content contains query:
+// ID, title, path and excerpt, separated by tabs.
+func findNotes(zettelHome, query string, ignoreCase bool) error {
+	err := filepath.Walk(zettelHome, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if isIgnored(zettelHome, path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || filepath.Ext(path) != noteExtension {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		id := noteID(path)
+		if !containsQuery(id, query, ignoreCase) && !containsQuery(string(content), query, ignoreCase) {
+			return nil
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatRecord(id, noteTitle(string(content), id), abs, matchExcerpt(string(content), query, ignoreCase)))
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("Search error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		want   string
+	}{
+		{"plain", []string{"a.md", "Title", "text"}, "a.md\tTitle\ttext"},
+		{"tab", []string{"a.md", "one\ttwo"}, `a.md` + "\t" + `one\ttwo`},
+		{"line breaks", []string{"first\nsecond\r\nthird"}, `first\nsecond\r\nthird`},
+		{"backslash", []string{`C:\notes`, `\t`}, `C:\\notes` + "\t" + `\\t`},
+		{"empty fields", []string{"", ""}, "\t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatRecord(tt.fields...)
+			if got != tt.want {
+				t.Errorf("formatRecord(%q) = %q, want %q", tt.fields, got, tt.want)
+			}
+			if strings.ContainsAny(got, "\n\r") || strings.Count(got, "\t") != len(tt.fields)-1 {
+				t.Errorf("formatRecord(%q) = %q is not one record of %d fields", tt.fields, got, len(tt.fields))
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// followLink opens the nth note id links to, counting only the links that
+// resolve to a single note. When n is 0 it lists the links, numbering
+// those that can be followed, and asks for one.
+func followLink(zettelHome, id string, n int) error {
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+	content, ok := r.contents[id]
+	if !ok {
+		return notFoundf("Note does not exist: %v", id)
+	}
+
+	var targets []string
+	links := outgoingLinks(r, content)
+	for _, link := range links {
+		if link.Resolved {
+			targets = append(targets, strings.TrimSuffix(link.Filename, noteExtension))
+		}
+	}
+	if len(targets) == 0 {
+		return notFoundf("No links to follow in %v", id)
+	}
+
+	if n == 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		number := 0
+		for _, link := range links {
+			switch {
+			case link.Resolved:
+				number++
+				fmt.Fprintf(w, "%d)\t%s\t%s\n", number, link.Target, link.Title)
+			case len(link.Candidates) > 0:
+				fmt.Fprintf(w, "-\t%s\t(ambiguous: %s)\n", link.Target, strings.Join(link.Candidates, ", "))
+			default:
+				fmt.Fprintf(w, "-\t%s\t(unresolved)\n", link.Target)
+			}
+		}
+		w.Flush()
+		fmt.Print("Follow link: ")
+
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if n, err = strconv.Atoi(strings.TrimSpace(answer)); err != nil {
+			return usagef("Invalid selection")
+		}
+	}
+	if n < 1 || n > len(targets) {
+		return usagef("No link %d in %s, which has %d to follow", n, id, len(targets))
+	}
+	return editNote(zettelHome, targets[n-1])
+}
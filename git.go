@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// autoCommit is set by the --commit flag.
+var autoCommit bool
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// recordChange is called by every command that changes notes once it has
+// succeeded: it brings the search index up to date and commits the change.
+// Under --dry-run nothing changed and it does nothing.
+func recordChange(zettelHome, message string) {
+	if dryRun {
+		return
+	}
+	updateSearchIndex(zettelHome)
+	commitVault(zettelHome, message)
+}
+
+// commitVault records the current state of the vault in git when
+// auto-commit is enabled by --commit or the config file. It does nothing if
+// the vault is not inside a git work tree or nothing changed, and only warns
+// on failure since the command itself has already succeeded.
+func commitVault(zettelHome, message string) {
+	if !autoCommit && !cfg.AutoCommit {
+		return
+	}
+	if err := runGit(zettelHome, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return
+	}
+
+	if err := runGit(zettelHome, "add", "-A", "."); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+		return
+	}
+	if runGit(zettelHome, "diff", "--cached", "--quiet") == nil {
+		return
+	}
+	if err := runGit(zettelHome, "commit", "-q", "-m", "zettel: "+message); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// linkGraph holds every note ID, its title and tags, and its outgoing links
+// to other existing notes.
+type linkGraph struct {
+	ids    []string
+	links  map[string][]string
+	titles map[string]string
+	tags   map[string][]string
+}
+
+// buildLinkGraph reads the notes in zettelHome, resolving each link the way
+// backlinks does, by filename, alias, slug or title. Links to archived notes
+// are left out.
+func buildLinkGraph(zettelHome string) (*linkGraph, error) {
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := sortedKeys(r.ids)
+	g := &linkGraph{
+		ids:    ids,
+		links:  make(map[string][]string, len(ids)),
+		titles: make(map[string]string, len(ids)),
+		tags:   make(map[string][]string, len(ids)),
+	}
+	for _, id := range ids {
+		content := r.contents[id]
+		g.titles[id] = noteTitle(content, id)
+		g.tags[id] = noteTags(content)
+
+		seen := map[string]bool{}
+		for _, target := range parseLinks(content) {
+			if resolved, _ := r.resolve(target); r.ids[resolved] && !seen[resolved] {
+				seen[resolved] = true
+				g.links[id] = append(g.links[id], resolved)
+			}
+		}
+		sort.Strings(g.links[id])
+	}
+
+	return g, nil
+}
+
+// distances returns the number of links between from and every note
+// reachable from it, following links in either direction.
+func (g *linkGraph) distances(from string) map[string]int {
+	neighbors := map[string][]string{}
+	for src, targets := range g.links {
+		for _, dest := range targets {
+			neighbors[src] = append(neighbors[src], dest)
+			neighbors[dest] = append(neighbors[dest], src)
+		}
+	}
+
+	dist := map[string]int{from: 0}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range neighbors[id] {
+			if _, ok := dist[next]; !ok {
+				dist[next] = dist[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return dist
+}
+
+// distanceColor shades nodes lighter the further they are from the focus.
+func distanceColor(distance int, reachable bool) string {
+	switch {
+	case !reachable:
+		return "white"
+	case distance == 0:
+		return "gold"
+	case distance == 1:
+		return "gray60"
+	case distance == 2:
+		return "gray75"
+	default:
+		return "gray90"
+	}
+}
+
+type graphNode struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Distance *int   `json:"distance,omitempty"`
+}
+
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// printGraph prints the link graph with notes labelled by their titles. With
+// tag set, only notes carrying it and the links between them are included.
+func printGraph(zettelHome, format, highlight, tag string) error {
+	g, err := buildLinkGraph(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error building graph: %w", err)
+	}
+
+	ids := g.ids
+	included := func(string) bool { return true }
+	if tag != "" {
+		keep := map[string]bool{}
+		ids = nil
+		for _, id := range g.ids {
+			if hasTags(g.tags[id], []string{tag}, false) {
+				keep[id] = true
+				ids = append(ids, id)
+			}
+		}
+		included = func(id string) bool { return keep[id] }
+	}
+
+	var dist map[string]int
+	if highlight != "" {
+		if _, err := os.Stat(filepath.Join(zettelHome, highlight+noteExtension)); os.IsNotExist(err) {
+			return notFoundf("Note does not exist: %v", highlight)
+		}
+		dist = g.distances(highlight)
+	}
+
+	switch format {
+	case "dot":
+		fmt.Println("digraph zettel {")
+		for _, id := range ids {
+			if dist == nil {
+				fmt.Printf("  %q [label=%q];\n", id, g.titles[id])
+				continue
+			}
+			d, ok := dist[id]
+			if !ok {
+				d = -1
+			}
+			fmt.Printf("  %q [label=%q, distance=%d, style=filled, fillcolor=%s];\n", id, g.titles[id], d, distanceColor(d, ok))
+		}
+		for _, id := range ids {
+			for _, dest := range g.links[id] {
+				if included(dest) {
+					fmt.Printf("  %q -> %q;\n", id, dest)
+				}
+			}
+		}
+		fmt.Println("}")
+	case "json":
+		out := struct {
+			Nodes []graphNode `json:"nodes"`
+			Edges []graphEdge `json:"edges"`
+		}{Nodes: []graphNode{}, Edges: []graphEdge{}}
+		for _, id := range ids {
+			node := graphNode{ID: id, Title: g.titles[id]}
+			if dist != nil {
+				d, ok := dist[id]
+				if !ok {
+					d = -1
+				}
+				node.Distance = &d
+			}
+			out.Nodes = append(out.Nodes, node)
+			for _, dest := range g.links[id] {
+				if !included(dest) {
+					continue
+				}
+				out.Edges = append(out.Edges, graphEdge{From: id, To: dest})
+			}
+		}
+		return printJSON(out)
+	default:
+		return usagef("Unknown graph format: %v", format)
+	}
+	return nil
+}
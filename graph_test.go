@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// graphTestNotes link a -> b -> c, and e to c by its title; d is unlinked.
+var graphTestNotes = map[string]string{
+	"a": "# A\n\n[[b]]\n",
+	"b": "# B\n\n[[c]]\n",
+	"c": "# C title\n",
+	"d": "# D\n",
+	"e": "# E\n\n[[C title]]\n",
+}
+
+func TestDistances(t *testing.T) {
+	g, err := buildLinkGraph(testVault(t, graphTestNotes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dist := g.distances("a")
+	want := map[string]int{"a": 0, "b": 1, "c": 2, "e": 3}
+	for id, d := range want {
+		if got, ok := dist[id]; !ok || got != d {
+			t.Errorf("distance to %s = %d (reachable %v), want %d", id, got, ok, d)
+		}
+	}
+	if _, ok := dist["d"]; ok {
+		t.Errorf("unlinked d is reachable at distance %d", dist["d"])
+	}
+}
+
+func TestGraphHighlightJSON(t *testing.T) {
+	out, code := runZettel(t, testVault(t, graphTestNotes), "graph", "--format", "json", "--highlight", "b")
+	if code != 0 {
+		t.Fatalf("graph exited %d: %s", code, out)
+	}
+	var graph struct {
+		Nodes []graphNode `json:"nodes"`
+		Edges []graphEdge `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(out), &graph); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"a": 1, "b": 0, "c": 1, "d": -1, "e": 2}
+	if len(graph.Nodes) != len(want) {
+		t.Fatalf("got %d nodes, want %d", len(graph.Nodes), len(want))
+	}
+	for _, node := range graph.Nodes {
+		if node.Distance == nil || *node.Distance != want[node.ID] {
+			t.Errorf("node %s has distance %v, want %d", node.ID, node.Distance, want[node.ID])
+		}
+	}
+	if len(graph.Edges) != 3 {
+		t.Errorf("got edges %v, want a->b, b->c and e->c", graph.Edges)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	historyFile  = ".zettel-history"
+	historyLimit = 100
+)
+
+func readHistory(zettelHome string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(zettelHome, historyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(content)), nil
+}
+
+func writeHistory(zettelHome string, stack []string) error {
+	if len(stack) > historyLimit {
+		stack = stack[len(stack)-historyLimit:]
+	}
+	content := strings.Join(stack, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return os.WriteFile(filepath.Join(zettelHome, historyFile), []byte(content), 0644)
+}
+
+// pushHistory records id as the most recently opened note.
+func pushHistory(zettelHome, id string) error {
+	stack, err := readHistory(zettelHome)
+	if err != nil {
+		return err
+	}
+	if len(stack) > 0 && stack[len(stack)-1] == id {
+		return nil
+	}
+	return writeHistory(zettelHome, append(stack, id))
+}
+
+// goBack drops the current note from the navigation stack and reopens the
+// one opened before it, skipping notes that no longer exist.
+func goBack(zettelHome string) error {
+	stack, err := readHistory(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading history: %w", err)
+	}
+
+	for len(stack) > 1 {
+		stack = stack[:len(stack)-1]
+		id := stack[len(stack)-1]
+		notePath := filepath.Join(zettelHome, id+noteExtension)
+		if _, err := os.Stat(notePath); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := writeHistory(zettelHome, stack); err != nil {
+			return fmt.Errorf("Error writing history: %w", err)
+		}
+		if err := openEditor(notePath); err != nil {
+			return fmt.Errorf("Error opening editor: %w", err)
+		}
+		return nil
+	}
+
+	return notFoundf("No previous note")
+}
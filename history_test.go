@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPushHistory(t *testing.T) {
+	home := testVault(t, nil)
+	for _, id := range []string{"a", "b", "b", "c", "a"} {
+		if err := pushHistory(home, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stack, err := readHistory(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "a"}; !slices.Equal(stack, want) {
+		t.Errorf("history = %v, want %v", stack, want)
+	}
+
+	for i := range historyLimit + 10 {
+		if err := pushHistory(home, fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stack, _ = readHistory(home); len(stack) != historyLimit || stack[len(stack)-1] != fmt.Sprint(historyLimit+9) {
+		t.Errorf("history keeps %d notes ending in %s, want the last %d", len(stack), stack[len(stack)-1], historyLimit)
+	}
+}
+
+func TestBack(t *testing.T) {
+	home := testVault(t, map[string]string{"a": "# A\n", "b": "# B\n", "c": "# C\n"})
+
+	out, code := runZettel(t, home, "back")
+	if code != exitNotFound || !strings.Contains(out, "No previous note") {
+		t.Errorf("back with no history: exit %d, output %q", code, out)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		runZettel(t, home, "edit", id)
+	}
+	tests := []struct {
+		code  int
+		stack []string
+	}{
+		{0, []string{"a", "b"}},
+		{0, []string{"a"}},
+		{exitNotFound, []string{"a"}},
+	}
+	for i, tt := range tests {
+		if _, code := runZettel(t, home, "back"); code != tt.code {
+			t.Errorf("back %d exited %d, want %d", i+1, code, tt.code)
+		}
+		if stack, _ := readHistory(home); !slices.Equal(stack, tt.stack) {
+			t.Errorf("after back %d history = %v, want %v", i+1, stack, tt.stack)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFile lists gitignore-style patterns, one per line, of files in the
+// notes directory that zettel should not treat as notes. A pattern matches
+// a file or directory name at any depth, or a path relative to the notes
+// directory if it contains a "/"; a trailing "/" only matches directories,
+// and everything below a matching directory is ignored too. Blank lines and
+// lines starting with "#" are skipped.
+const ignoreFile = ".zettelignore"
+
+type ignoreRule struct {
+	pattern  string
+	anchored bool
+	dirOnly  bool
+}
+
+// ignoreRules caches the parsed ignore file of each notes directory for the
+// rest of the invocation.
+var ignoreRules = map[string][]ignoreRule{}
+
+func loadIgnoreRules(zettelHome string) []ignoreRule {
+	if rules, ok := ignoreRules[zettelHome]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	content, err := os.ReadFile(filepath.Join(zettelHome, ignoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "Warning: reading "+ignoreFile+":", err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		line, rule.dirOnly = strings.CutSuffix(line, "/")
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		rules = append(rules, rule)
+	}
+
+	ignoreRules[zettelHome] = rules
+	return rules
+}
+
+// isIgnored reports whether path, a file in zettelHome or a directory if
+// isDir is set, matches the ignore file.
+func isIgnored(zettelHome, path string, isDir bool) bool {
+	rules := loadIgnoreRules(zettelHome)
+	if len(rules) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(zettelHome, path)
+	if err != nil || rel == "." {
+		return false
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, name := range parts {
+		dir := isDir || i < len(parts)-1
+		prefix := strings.Join(parts[:i+1], "/")
+		for _, rule := range rules {
+			if rule.dirOnly && !dir {
+				continue
+			}
+			target := name
+			if rule.anchored {
+				target = prefix
+			}
+			if ok, _ := filepath.Match(rule.pattern, target); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// relativeLinkRegex matches an inline markdown link or image, capturing the
+// '!' of images, the link text and the target.
+var relativeLinkRegex = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)\)`)
+
+// importTitle names an imported file after its first "# " heading, or else
+// its filename.
+func importTitle(content, path string) string {
+	if title := noteTitle(content, ""); title != "" {
+		return title
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// importLinks rewrites the relative links in content, a file imported from
+// dir, into wikilinks when they point at another imported file, found in
+// imported by absolute path, or at a note already in the vault.
+func importLinks(zettelHome, dir, content string, imported map[string]string) string {
+	return relativeLinkRegex.ReplaceAllStringFunc(content, func(link string) string {
+		m := relativeLinkRegex.FindStringSubmatch(link)
+		image, text, target := m[1], m[2], m[3]
+		if image != "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+			return link
+		}
+		target, fragment, _ := strings.Cut(target, "#")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		if filepath.Ext(target) != noteExtension || filepath.IsAbs(target) {
+			return link
+		}
+
+		id, ok := imported[filepath.Join(dir, filepath.FromSlash(target))]
+		if !ok {
+			id = strings.TrimSuffix(filepath.Base(target), noteExtension)
+			if _, err := os.Stat(notePath(zettelHome, id)); err != nil {
+				return link
+			}
+		}
+		if fragment != "" {
+			id += "#" + fragment
+		}
+		if text == "" || text == id {
+			return "[[" + id + "]]"
+		}
+		return "[[" + id + "|" + text + "]]"
+	})
+}
+
+// importNotes copies markdown files into the vault as new notes named after
+// their titles, turning the relative links between them, and to notes
+// already in the vault, into wikilinks. With move the originals are deleted
+// once every file is imported.
+func importNotes(zettelHome string, paths []string, move bool) error {
+	type importedFile struct {
+		path, id string
+		content  string
+	}
+	var files []importedFile
+	imported := map[string]string{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("Error importing: %w", err)
+		}
+		if filepath.Ext(abs) != noteExtension {
+			return usagef("Not a markdown file: %v", path)
+		}
+		if _, ok := imported[abs]; ok {
+			continue
+		}
+		content, err := os.ReadFile(abs)
+		if err != nil {
+			return fmt.Errorf("Error reading file: %w", err)
+		}
+
+		id, f, err := createNoteFile(zettelHome, newNoteID(importTitle(string(content), abs), false))
+		if err != nil {
+			return fmt.Errorf("Error creating note: %w", err)
+		}
+		f.Close()
+		imported[abs] = id
+		files = append(files, importedFile{abs, id, string(content)})
+	}
+
+	for _, file := range files {
+		content := importLinks(zettelHome, filepath.Dir(file.path), file.content, imported)
+		if err := writeFileAtomic(notePath(zettelHome, file.id), []byte(content)); err != nil {
+			return fmt.Errorf("Error writing note: %w", err)
+		}
+		fmt.Printf("%s -> %s\n", file.path, file.id+noteExtension)
+	}
+
+	if move {
+		for _, file := range files {
+			if err := os.Remove(file.path); err != nil {
+				return fmt.Errorf("Error removing original: %w", err)
+			}
+		}
+	}
+	recordChange(zettelHome, fmt.Sprintf("import %d notes", len(files)))
+	return nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// indexEndMarker closes the generated link list of an index note. The start
+// marker records the tags the list was built from, as in
+// "<!-- zettel:index:start tags=go,cli -->".
+const indexEndMarker = "<!-- zettel:index:end -->"
+
+func indexStartLine(tags []string) string {
+	return indexStartMarker + " tags=" + strings.Join(tags, ",") + " -->"
+}
+
+// indexTags reads the tags an index note was built from out of its start
+// marker.
+func indexTags(content string) ([]string, error) {
+	start := strings.Index(content, indexStartMarker)
+	if start < 0 || !strings.Contains(content[start:], indexEndMarker) {
+		return nil, errors.New("no index markers")
+	}
+	line, _, _ := strings.Cut(content[start+len(indexStartMarker):], "-->")
+	value, ok := strings.CutPrefix(strings.TrimSpace(line), "tags=")
+	if !ok || value == "" {
+		return nil, errors.New("index start marker lists no tags")
+	}
+	return strings.Split(value, ","), nil
+}
+
+// indexLinks returns the link list of an index of the notes carrying all of
+// tags, leaving out the index note itself.
+func indexLinks(zettelHome, self string, tags []string) (string, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, id := range ids {
+		if id == self {
+			continue
+		}
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return "", err
+		}
+		if !hasTags(noteTags(content), tags, false) {
+			continue
+		}
+		if title := noteTitle(content, id); title != id {
+			fmt.Fprintf(&b, "- [[%s|%s]]\n", id, title)
+		} else {
+			fmt.Fprintf(&b, "- [[%s]]\n", id)
+		}
+	}
+	return b.String(), nil
+}
+
+// replaceIndexSection swaps the link list between the index markers of
+// content for links, keeping everything around it. Content without markers
+// gets them appended after its own text.
+func replaceIndexSection(content string, tags []string, links string) string {
+	section := indexStartLine(tags) + "\n" + links + indexEndMarker + "\n"
+
+	start := strings.Index(content, indexStartMarker)
+	if start < 0 {
+		return strings.TrimRight(content, "\n") + "\n\n" + section
+	}
+	end := strings.Index(content[start:], indexEndMarker)
+	if end < 0 {
+		return content[:start] + section
+	}
+	end += start + len(indexEndMarker)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:start] + section + content[end:]
+}
+
+// rebuildIndex regenerates the link list of the index note id, reporting
+// whether the note changed.
+func rebuildIndex(zettelHome, id string, tags []string) (bool, error) {
+	content, err := readNote(zettelHome, id)
+	if err != nil {
+		return false, err
+	}
+	links, err := indexLinks(zettelHome, id, tags)
+	if err != nil {
+		return false, err
+	}
+
+	updated := replaceIndexSection(content, tags, links)
+	if updated == content {
+		return false, nil
+	}
+	return true, writeFileAtomic(notePath(zettelHome, id), []byte(updated))
+}
+
+// createIndexNote creates a note titled title listing the notes with all
+// of tags between index markers, which reindex and watch-index regenerate.
+func createIndexNote(zettelHome, title string, tags []string) error {
+	if title == "" {
+		title = "Index " + strings.Join(tags, " ")
+	}
+	id, f, err := createNoteFile(zettelHome, newNoteID(title, false))
+	if err != nil {
+		return fmt.Errorf("Error creating note: %w", err)
+	}
+
+	links, err := indexLinks(zettelHome, id, tags)
+	if err == nil {
+		_, err = f.WriteString(replaceIndexSection("# "+title+"\n", tags, links))
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("Error creating index: %w", err)
+	}
+
+	recordChange(zettelHome, "new index "+id)
+	fmt.Println(id + noteExtension)
+	return nil
+}
+
+// reindexNote rebuilds the link list of an existing index note from the
+// tags recorded in its start marker.
+func reindexNote(zettelHome, id string) error {
+	content, err := readNote(zettelHome, id)
+	if os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+
+	tags, err := indexTags(content)
+	if err != nil {
+		return fmt.Errorf("Error: %s is not an index note: %w", id, err)
+	}
+
+	changed, err := rebuildIndex(zettelHome, id, tags)
+	if err != nil {
+		return fmt.Errorf("Error rebuilding index: %w", err)
+	}
+	if !changed {
+		fmt.Println("Index is up to date:", id)
+		return nil
+	}
+	fmt.Println("Updated", id)
+	recordChange(zettelHome, "reindex "+id)
+	return nil
+}
+
+// noteModTimes records the modification time of every note but skip, to
+// tell when the notes directory changed.
+func noteModTimes(zettelHome, skip string) (map[string]time.Time, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time, len(ids))
+	for _, id := range ids {
+		if id == skip {
+			continue
+		}
+		info, err := os.Stat(notePath(zettelHome, id))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		times[id] = info.ModTime()
+	}
+	return times, nil
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, t := range a {
+		if !b[id].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	watchInterval = 500 * time.Millisecond
+	// watchSettle is how long the notes must stay unchanged before the
+	// index is rebuilt, so a burst of saves triggers a single rebuild.
+	watchSettle = time.Second
+)
+
+// watchIndex keeps the link list of the index note id up to date with the
+// notes carrying tags until interrupted. The notes directory is polled
+// rather than watched through file system events.
+func watchIndex(zettelHome, id string, tags []string) error {
+	if _, err := os.Stat(notePath(zettelHome, id)); os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	}
+
+	rebuild := func() {
+		changed, err := rebuildIndex(zettelHome, id, tags)
+		if err != nil {
+			fmt.Println("Error rebuilding index:", err)
+			return
+		}
+		if changed {
+			fmt.Println(time.Now().Format("15:04:05"), "Updated", id)
+		}
+	}
+	rebuild()
+
+	last, err := noteModTimes(zettelHome, id)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	fmt.Printf("Watching %s for notes tagged #%s (Ctrl-C to stop)\n", zettelHome, strings.Join(tags, " #"))
+	var changedAt time.Time
+	for {
+		select {
+		case <-interrupt:
+			fmt.Println()
+			return nil
+		case now := <-ticker.C:
+			current, err := noteModTimes(zettelHome, id)
+			if err != nil {
+				fmt.Println("Error listing notes:", err)
+				continue
+			}
+			if !sameModTimes(last, current) {
+				last = current
+				changedAt = now
+				continue
+			}
+			if !changedAt.IsZero() && now.Sub(changedAt) >= watchSettle {
+				changedAt = time.Time{}
+				rebuild()
+			}
+		}
+	}
+}
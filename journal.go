@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// dailyNoteID names the journal note of a day, so that every invocation for
+// the same day resolves to the same file.
+func dailyNoteID(day time.Time) string {
+	return day.Format("20060102") + "-daily"
+}
+
+// openDailyNote opens the journal note for day, creating it with a dated
+// heading and the #daily tag if it does not exist yet.
+func openDailyNote(zettelHome string, day time.Time) error {
+	if err := os.MkdirAll(zettelHome, 0755); err != nil {
+		return fmt.Errorf("Error creating directory: %w", err)
+	}
+
+	id := dailyNoteID(day)
+	notePath := filepath.Join(zettelHome, id+noteExtension)
+	if _, err := os.Stat(notePath); os.IsNotExist(err) {
+		content := "# " + day.Format("Monday, January 2, 2006") + "\n\n#daily\n"
+		if err := os.WriteFile(notePath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("Error creating note: %w", err)
+		}
+	}
+
+	return editNote(zettelHome, id)
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// replaceLink rewrites every [[oldDest]] link in src so that it points at
+// newDest, keeping any #section or |alias suffix intact.
+func replaceLink(zettelHome, src, oldDest, newDest string) error {
+	srcPath := filepath.Join(zettelHome, src+noteExtension)
+	destPath := filepath.Join(zettelHome, newDest+noteExtension)
+
+	content, err := os.ReadFile(srcPath)
+	if os.IsNotExist(err) {
+		return notFoundf("Source note does not exist: %v", src)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+
+	if _, err := os.Stat(destPath); os.IsNotExist(err) {
+		return notFoundf("Destination note does not exist: %v", newDest)
+	}
+
+	updated, n := replaceLinks(string(content), func(target string) (string, bool) {
+		return newDest, target == oldDest
+	})
+	if n == 0 {
+		return notFoundf("No link to %s found in %s", oldDest, src)
+	}
+
+	if err := stageWrite(zettelHome, srcPath, []byte(updated), fmt.Sprintf("relink %d links", n)); err != nil {
+		return fmt.Errorf("Error writing note: %w", err)
+	}
+	if dryRun {
+		return nil
+	}
+
+	recordChange(zettelHome, "relink "+src+": "+oldDest+" -> "+newDest)
+	fmt.Printf("Relinked %s: %s -> %s\n", src, oldDest, newDest)
+	return nil
+}
+
+var wikiLinkRegex = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+
+// replaceLinks calls replace with the target of every [[...]] link in
+// content, as parseLinks returns it. When replace reports ok, the link is
+// pointed at the target it returns, keeping any extension, #section or
+// |alias. It returns the new content and the number of links changed.
+func replaceLinks(content string, replace func(target string) (string, bool)) (string, int) {
+	n := 0
+	updated := wikiLinkRegex.ReplaceAllStringFunc(content, func(link string) string {
+		inner := link[2 : len(link)-2]
+		end := strings.IndexAny(inner, "#|")
+		if end < 0 {
+			end = len(inner)
+		}
+		written := strings.TrimSpace(inner[:end])
+		if written == "" {
+			return link
+		}
+		target, ok := replace(noteID(written))
+		if !ok {
+			return link
+		}
+		if strings.HasSuffix(written, noteExtension) {
+			target += noteExtension
+		}
+		n++
+		return "[[" + target + inner[end:] + "]]"
+	})
+	return updated, n
+}
+
+// rewriteLinks points the links of content that r resolves to oldID at
+// newID, with next resolving links as they will be once the note has moved.
+// A link next still resolves to newID, such as by heading or alias, is kept
+// as written; a link by slug gets the slug of newID; any other gets newID
+// itself. It returns the new content and the number of links rewritten.
+func rewriteLinks(content string, r, next *linkResolver, oldID, newID string) (string, int) {
+	return replaceLinks(content, func(target string) (string, bool) {
+		if resolved, _ := r.resolve(target); resolved != oldID {
+			return "", false
+		}
+		if resolved, _ := next.resolve(target); resolved == newID {
+			return "", false
+		}
+		_, alias := lookupAlias(r.aliases, target)
+		if !r.ids[target] && !alias && len(r.slugs[strings.ToLower(slugify(target))]) > 0 {
+			if slug := idSlug(newID); slug != "" {
+				if resolved, _ := next.resolve(slug); resolved == newID {
+					return slug, true
+				}
+			}
+		}
+		return newID, true
+	})
+}
+
+// removeLinks strips every link of content that r resolves to id, and the
+// spaces this leaves at the end of a line. Lines left empty are dropped
+// along with the blank line appendLink puts before a link. It returns the
+// new content and the number of links removed.
+func removeLinks(content string, r *linkResolver, id string) (string, int) {
+	removed := 0
+	var kept []string
+	for _, line := range strings.Split(content, "\n") {
+		n := 0
+		line = wikiLinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+			for _, target := range parseLinks(link) {
+				if resolved, _ := r.resolve(target); resolved == id {
+					n++
+					return ""
+				}
+			}
+			return link
+		})
+		if n == 0 {
+			kept = append(kept, line)
+			continue
+		}
+		removed += n
+		if line = strings.TrimRight(line, " \t"); strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		} else if len(kept) > 0 && kept[len(kept)-1] == "" {
+			kept = kept[:len(kept)-1]
+		}
+	}
+	return strings.Join(kept, "\n"), removed
+}
+
+// parseLinks returns the note IDs targeted by the [[...]] links in content,
+// with any extension, #section or |alias stripped.
+func parseLinks(content string) []string {
+	var targets []string
+	for _, match := range wikiLinkRegex.FindAllStringSubmatch(content, -1) {
+		target := match[1]
+		if i := strings.IndexAny(target, "#|"); i >= 0 {
+			target = target[:i]
+		}
+		if target = strings.TrimSpace(target); target != "" {
+			targets = append(targets, noteID(target))
+		}
+	}
+	return targets
+}
+
+// linkResolver resolves [[...]] link targets to note IDs. A target is
+// matched against the note filenames first, then against the aliases in
+// .aliases, then against the slug part of
+// the filenames, which new and rename derive from the title, and last
+// against the first "# heading" of each note. The last two are
+// case-insensitive. Targets matching no note are looked up the same way
+// among the archived notes.
+type linkResolver struct {
+	ids      map[string]bool
+	slugs    map[string][]string
+	headings map[string][]string
+	contents map[string]string
+	aliases  map[string]string
+	archived *linkResolver
+}
+
+func newLinkResolver(zettelHome string) (*linkResolver, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := listArchivedIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &linkResolver{
+		ids:      make(map[string]bool, len(ids)),
+		slugs:    map[string][]string{},
+		headings: map[string][]string{},
+		contents: make(map[string]string, len(ids)),
+	}
+	if r.aliases, err = readAliases(zettelHome); err != nil {
+		return nil, err
+	}
+	r.archived = &linkResolver{
+		ids:      make(map[string]bool, len(archived)),
+		slugs:    map[string][]string{},
+		headings: map[string][]string{},
+		contents: make(map[string]string, len(archived)),
+		aliases:  r.aliases,
+	}
+
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return nil, err
+		}
+		r.add(id, content)
+	}
+	for _, id := range archived {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return nil, err
+		}
+		r.archived.add(filepath.Base(id), content)
+	}
+	return r, nil
+}
+
+// add indexes the note id by its filename, slug and heading.
+func (r *linkResolver) add(id, content string) {
+	r.ids[id] = true
+	r.contents[id] = content
+
+	if slug := idSlug(id); slug != "" {
+		key := strings.ToLower(slug)
+		r.slugs[key] = append(r.slugs[key], id)
+	}
+	if title := noteTitle(content, id); title != id {
+		key := strings.ToLower(strings.TrimSpace(title))
+		r.headings[key] = append(r.headings[key], id)
+	}
+}
+
+// resolve returns the note target refers to, with archived notes given as
+// archive/<ID>. When target matches several notes at the same step, id is
+// empty and candidates lists them; when it matches none, both are empty.
+func (r *linkResolver) resolve(target string) (id string, candidates []string) {
+	if r.ids[target] {
+		return target, nil
+	}
+	if id, ok := lookupAlias(r.aliases, target); ok && r.ids[id] {
+		return id, nil
+	}
+	for _, matches := range [][]string{
+		r.slugs[strings.ToLower(slugify(target))],
+		r.headings[strings.ToLower(strings.TrimSpace(target))],
+	} {
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		default:
+			return "", matches
+		}
+	}
+	if r.archived == nil {
+		return "", nil
+	}
+
+	id, candidates = r.archived.resolve(target)
+	if id != "" {
+		return filepath.Join(archiveDir, id), nil
+	}
+	var archived []string
+	for _, c := range candidates {
+		archived = append(archived, filepath.Join(archiveDir, c))
+	}
+	return "", archived
+}
+
+// moved returns r as it will be once the note oldID is renamed to newID, or
+// merged into newID when that note exists. The aliases of oldID follow it.
+func (r *linkResolver) moved(oldID, newID string) *linkResolver {
+	next := &linkResolver{
+		ids:      make(map[string]bool, len(r.ids)),
+		slugs:    map[string][]string{},
+		headings: map[string][]string{},
+		contents: make(map[string]string, len(r.contents)),
+		aliases:  make(map[string]string, len(r.aliases)),
+		archived: r.archived,
+	}
+	for _, id := range sortedKeys(r.contents) {
+		if id != oldID {
+			next.add(id, r.contents[id])
+		}
+	}
+	if !next.ids[newID] {
+		next.add(newID, r.contents[oldID])
+	}
+	for name, id := range r.aliases {
+		if id == oldID {
+			id = newID
+		}
+		next.aliases[name] = id
+	}
+	return next
+}
+
+// linksTo reports whether content has a link r resolves to id.
+func (r *linkResolver) linksTo(content, id string) bool {
+	for _, target := range parseLinks(content) {
+		if resolved, _ := r.resolve(target); resolved == id {
+			return true
+		}
+	}
+	return false
+}
+
+// content returns the content of the note id, which may be archived.
+func (r *linkResolver) content(id string) string {
+	if content, ok := r.contents[id]; ok || r.archived == nil {
+		return content
+	}
+	return r.archived.contents[filepath.Base(id)]
+}
+
+// backlinks returns the IDs of the notes linking to id, by filename or by
+// title.
+func backlinks(zettelHome, id string) ([]string, error) {
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	for _, src := range sortedKeys(r.contents) {
+		for _, target := range parseLinks(r.contents[src]) {
+			if resolved, _ := r.resolve(target); resolved == id {
+				sources = append(sources, src)
+				break
+			}
+		}
+	}
+
+	return sources, nil
+}
+
+func printBacklinks(zettelHome, id string) error {
+	sources, err := backlinks(zettelHome, id)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	for _, src := range sources {
+		fmt.Println(src)
+	}
+	return nil
+}
+
+// outgoingLink is a distinct [[...]] target of a note and what it resolves
+// to.
+type outgoingLink struct {
+	Target     string   `json:"target"`
+	Filename   string   `json:"filename,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Resolved   bool     `json:"resolved"`
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// outgoingLinks returns the distinct [[...]] targets of content in order,
+// resolved against r.
+func outgoingLinks(r *linkResolver, content string) []outgoingLink {
+	links := []outgoingLink{}
+	seen := map[string]bool{}
+	for _, target := range parseLinks(content) {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		link := outgoingLink{Target: target}
+		resolved, candidates := r.resolve(target)
+		if resolved != "" {
+			link.Filename = resolved + noteExtension
+			link.Title = noteTitle(r.content(resolved), resolved)
+			link.Resolved = true
+		}
+		for _, c := range candidates {
+			link.Candidates = append(link.Candidates, c+noteExtension)
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// printOutgoingLinks lists the distinct [[...]] targets of a note in order,
+// each with the file and title of the note it resolves to.
+func printOutgoingLinks(zettelHome, id string) error {
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+	content, ok := r.contents[id]
+	if !ok {
+		return notFoundf("Note does not exist: %v", id)
+	}
+
+	links := outgoingLinks(r, content)
+	if jsonOutput {
+		return printJSON(links)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, link := range links {
+		switch {
+		case link.Resolved:
+			fmt.Fprintf(w, "%s\t%s\t%s\n", link.Target, link.Filename, link.Title)
+		case len(link.Candidates) > 0:
+			fmt.Fprintf(w, "%s\t-\t(ambiguous: %s)\n", link.Target, strings.Join(link.Candidates, ", "))
+		default:
+			fmt.Fprintf(w, "%s\t-\t(unresolved)\n", link.Target)
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+// reportBrokenLinks prints every link that resolves to no note, or to more
+// than one, and returns how many there were.
+func reportBrokenLinks(zettelHome string) (int, error) {
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return 0, err
+	}
+
+	broken := 0
+	for _, id := range sortedKeys(r.contents) {
+		for _, target := range parseLinks(r.contents[id]) {
+			resolved, candidates := r.resolve(target)
+			switch {
+			case len(candidates) > 0:
+				fmt.Printf("%s: ambiguous link [[%s]] matches %s\n", id, target, strings.Join(candidates, ", "))
+				broken++
+			case resolved == "":
+				fmt.Printf("%s: broken link [[%s]]\n", id, target)
+				broken++
+			}
+		}
+	}
+
+	return broken, nil
+}
+
+// idSlug returns the part of id after its timestamp, which new and rename
+// derive from the title.
+func idSlug(id string) string {
+	prefix, _, _ := idTimestamp(id)
+	return strings.TrimPrefix(id[len(prefix):], "-")
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
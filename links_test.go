@@ -0,0 +1,298 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// linkTestNotes is a vault whose notes link to 20240101120000-beta-notes by
+// ID, slug, title and alias.
+var linkTestNotes = map[string]string{
+	"20240101120000-beta-notes": "# Beta Notes\n",
+	"20240102120000-other":      "# Other\n",
+	"20240103120000-ids":        "# IDs\n\n[[20240101120000-beta-notes]] [[20240101120000-beta-notes.md#Part|this]]\n",
+	"20240104120000-slug":       "# Slug\n\nsee [[beta-notes]] and [[other]]\n",
+	"20240105120000-title":      "# Title\n\n[[Beta Notes]]\n",
+	"20240106120000-alias":      "# Alias\n\n[[bn|B]]\n",
+}
+
+func linkTestVault(t *testing.T) string {
+	t.Helper()
+	home := testVault(t, linkTestNotes)
+	if err := os.WriteFile(filepath.Join(home, aliasesFile), []byte("bn 20240101120000-beta-notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestRewriteLinks(t *testing.T) {
+	r, err := newLinkResolver(linkTestVault(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const oldID, newID = "20240101120000-beta-notes", "20240101120000-gamma"
+	next := r.moved(oldID, newID)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		n       int
+	}{
+		{"id", "see [[20240101120000-beta-notes]]\n", "see [[20240101120000-gamma]]\n", 1},
+		{"extension, section and alias", "[[20240101120000-beta-notes.md#Part|the old one]]", "[[20240101120000-gamma.md#Part|the old one]]", 1},
+		{"slug", "[[beta-notes]] and [[Beta-Notes#Part]]", "[[gamma]] and [[gamma#Part]]", 2},
+		{"title kept", "[[Beta Notes]]", "[[Beta Notes]]", 0},
+		{"alias kept", "[[bn]]", "[[bn]]", 0},
+		{"other notes untouched", "[[20240102120000-other]] [[other]] [[beta]]", "[[20240102120000-other]] [[other]] [[beta]]", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := rewriteLinks(tt.content, r, next, oldID, newID)
+			if got != tt.want || n != tt.n {
+				t.Errorf("rewriteLinks(%q) = %q, %d; want %q, %d", tt.content, got, n, tt.want, tt.n)
+			}
+		})
+	}
+
+	merged := r.moved(oldID, "20240102120000-other")
+	if got, _ := rewriteLinks("[[beta-notes]] [[Beta Notes]] [[bn]]", r, merged, oldID, "20240102120000-other"); got != "[[other]] [[other]] [[bn]]" {
+		t.Errorf("rewriteLinks into an existing note = %q", got)
+	}
+}
+
+func TestRemoveLinks(t *testing.T) {
+	r, err := newLinkResolver(linkTestVault(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		content string
+		want    string
+		n       int
+	}{
+		{"# A\n\n[[beta-notes]]\n", "# A\n", 1},
+		{"see [[Beta Notes]] and [[other]]\n", "see  and [[other]]\n", 1},
+		{"[[bn|B]] [[20240101120000-beta-notes.md]]\ntext\n", "text\n", 2},
+		{"[[beta]] [[other]]\n", "[[beta]] [[other]]\n", 0},
+	}
+	for _, tt := range tests {
+		got, n := removeLinks(tt.content, r, "20240101120000-beta-notes")
+		if got != tt.want || n != tt.n {
+			t.Errorf("removeLinks(%q) = %q, %d; want %q, %d", tt.content, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestRenameRelinks(t *testing.T) {
+	home := linkTestVault(t)
+	if _, code := runZettel(t, home, "rename", "20240101120000-beta-notes", "Gamma"); code != 0 {
+		t.Fatalf("rename exited %d", code)
+	}
+	want := map[string]string{
+		"20240103120000-ids":   "# IDs\n\n[[20240101120000-gamma]] [[20240101120000-gamma.md#Part|this]]\n",
+		"20240104120000-slug":  "# Slug\n\nsee [[gamma]] and [[other]]\n",
+		"20240105120000-title": linkTestNotes["20240105120000-title"],
+		"20240106120000-alias": linkTestNotes["20240106120000-alias"],
+	}
+	for id, content := range want {
+		if got := readTestNote(t, home, id); got != content {
+			t.Errorf("%s = %q, want %q", id, got, content)
+		}
+	}
+	if out, code := runZettel(t, home, "doctor"); code != 0 {
+		t.Errorf("doctor after rename exited %d: %s", code, out)
+	}
+}
+
+func TestDeleteRemovesLinks(t *testing.T) {
+	home := linkTestVault(t)
+	if _, code := runZettel(t, home, "delete", "--force", "20240101120000-beta-notes"); code != 0 {
+		t.Fatalf("delete exited %d", code)
+	}
+	want := map[string]string{
+		"20240103120000-ids":   "# IDs\n",
+		"20240104120000-slug":  "# Slug\n\nsee  and [[other]]\n",
+		"20240105120000-title": "# Title\n",
+		"20240106120000-alias": "# Alias\n",
+	}
+	for id, content := range want {
+		if got := readTestNote(t, home, id); got != content {
+			t.Errorf("%s = %q, want %q", id, got, content)
+		}
+	}
+	if out, code := runZettel(t, home, "doctor"); code != 0 {
+		t.Errorf("doctor after delete exited %d: %s", code, out)
+	}
+}
+
+func TestLinkReplace(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"src":   "# Src\n\nsee [[wrong#Intro|this]] and [[other]]\n",
+		"wrong": "# Wrong\n",
+		"right": "# Right\n",
+		"other": "# Other\n",
+	})
+
+	if _, code := runZettel(t, home, "link", "--replace", "wrong", "src", "right"); code != 0 {
+		t.Fatalf("link --replace exited %d", code)
+	}
+	if got, want := readTestNote(t, home, "src"), "# Src\n\nsee [[right#Intro|this]] and [[other]]\n"; got != want {
+		t.Errorf("src = %q, want %q", got, want)
+	}
+
+	out, code := runZettel(t, home, "link", "--replace", "wrong", "src", "right")
+	if code != exitNotFound || !strings.Contains(out, "No link to wrong found in src") {
+		t.Errorf("replacing a missing link: exit %d, output %q; want exit %d", code, out, exitNotFound)
+	}
+}
+
+func TestLinkOnce(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"a": "# A\n\nsee [[b#Part|B]]\n",
+		"b": "# B\n",
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, code := runZettel(t, home, "link", "--bidirectional", "a", "b"); code != 0 {
+			t.Fatalf("link exited %d", code)
+		}
+	}
+	if got, want := readTestNote(t, home, "a"), "# A\n\nsee [[b#Part|B]]\n"; got != want {
+		t.Errorf("a = %q, want the existing link kept alone", got)
+	}
+	if got, want := readTestNote(t, home, "b"), "# B\n\n[[a]]\n"; got != want {
+		t.Errorf("b = %q, want %q", got, want)
+	}
+}
+
+func TestResolveArchived(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"20240101120000-old-idea":        "# Old idea\n",
+		"archive/20230101120000-retired": "# Retired note\n",
+		"archive/20230101120000-twin":    "# Twin\n",
+		"archive/20230201120000-twin":    "# Twin\n",
+		"20240101120000-current":         "# Current\n",
+	})
+	r, err := newLinkResolver(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target     string
+		want       string
+		candidates []string
+	}{
+		{"20240101120000-old-idea", "20240101120000-old-idea", nil},
+		{"20230101120000-retired", "archive/20230101120000-retired", nil},
+		{"retired", "archive/20230101120000-retired", nil},
+		{"Retired note", "archive/20230101120000-retired", nil},
+		{"twin", "", []string{"archive/20230101120000-twin", "archive/20230201120000-twin"}},
+		{"missing", "", nil},
+	}
+	for _, tt := range tests {
+		got, candidates := r.resolve(tt.target)
+		sort.Strings(candidates)
+		if got != filepath.FromSlash(tt.want) || strings.Join(candidates, ",") != filepath.FromSlash(strings.Join(tt.candidates, ",")) {
+			t.Errorf("resolve(%q) = %q, %q; want %q, %q", tt.target, got, candidates, tt.want, tt.candidates)
+		}
+	}
+	if got := r.content("archive/20230101120000-retired"); got != "# Retired note\n" {
+		t.Errorf("content of an archived note = %q", got)
+	}
+}
+
+func TestLinkResolver(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"20240101120000-zettel-method": "# The Zettelkasten Method\n",
+		"20240102120000-reading-list":  "# Books\n",
+		"20240103120000-ideas":         "# Ideas\n",
+		"20240104120000-ideas":         "# More ideas\n",
+		"20240105120000-summary":       "# Weekly recap\n",
+		"20240106120000-recap":         "# Weekly recap\n",
+		"notes":                        "# Loose notes\n",
+	})
+	aliases := "zk 20240101120000-zettel-method\nbooks 20240103120000-ideas\n"
+	if err := os.WriteFile(filepath.Join(home, aliasesFile), []byte(aliases), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newLinkResolver(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, target, want string
+		candidates         []string
+	}{
+		{"id", "20240102120000-reading-list", "20240102120000-reading-list", nil},
+		{"id without timestamp", "notes", "notes", nil},
+		{"alias", "ZK", "20240101120000-zettel-method", nil},
+		{"alias before heading", "Books", "20240103120000-ideas", nil},
+		{"slug", "Zettel Method", "20240101120000-zettel-method", nil},
+		{"heading", "the zettelkasten method", "20240101120000-zettel-method", nil},
+		{"ambiguous slug", "ideas", "", []string{"20240103120000-ideas", "20240104120000-ideas"}},
+		{"slug before heading", "Recap", "20240106120000-recap", nil},
+		{"ambiguous heading", "weekly recap", "", []string{"20240105120000-summary", "20240106120000-recap"}},
+		{"none", "nowhere", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, candidates := r.resolve(tt.target)
+			sort.Strings(candidates)
+			if got != tt.want || strings.Join(candidates, ",") != strings.Join(tt.candidates, ",") {
+				t.Errorf("resolve(%q) = %q, %q; want %q, %q", tt.target, got, candidates, tt.want, tt.candidates)
+			}
+		})
+	}
+}
+
+func TestLinkDryRun(t *testing.T) {
+	notes := map[string]string{
+		"src":   "# Src\n\nsee [[wrong]]\n",
+		"wrong": "# Wrong\n",
+		"right": "# Right\n",
+	}
+	home := testVault(t, notes)
+
+	for _, args := range [][]string{
+		{"--dry-run", "link", "--replace", "wrong", "src", "right"},
+		{"--dry-run", "link", "--bidirectional", "src", "right"},
+	} {
+		out, code := runZettel(t, home, args...)
+		if code != 0 || !strings.HasPrefix(out, "write src"+noteExtension+": ") {
+			t.Errorf("%v exited %d printing %q", args, code, out)
+		}
+		for id, content := range notes {
+			if got := readTestNote(t, home, id); got != content {
+				t.Errorf("%v changed %s to %q", args, id, got)
+			}
+		}
+	}
+}
+
+func TestLinkOnceByTitle(t *testing.T) {
+	notes := map[string]string{
+		"20240101120000-src":        "# Src\n\n[[Beta Notes]] [[bn]]\n",
+		"20240102120000-beta-notes": "# Beta Notes\n",
+		"20240103120000-gamma":      "# Gamma\n",
+	}
+	home := testVault(t, notes)
+	if err := os.WriteFile(filepath.Join(home, aliasesFile), []byte("bn 20240103120000-gamma\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dest := range []string{"20240102120000-beta-notes", "20240103120000-gamma"} {
+		out, code := runZettel(t, home, "link", "20240101120000-src", dest)
+		if code != 0 || !strings.HasPrefix(out, "Already linked") {
+			t.Errorf("link to %s exited %d printing %q", dest, code, out)
+		}
+	}
+	if got := readTestNote(t, home, "20240101120000-src"); got != notes["20240101120000-src"] {
+		t.Errorf("src = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type lintIssue struct {
+	line    int
+	message string
+}
+
+// lintRule is a single prose check. fix is nil for rules that cannot be
+// corrected mechanically.
+type lintRule struct {
+	check func(lines []string) []lintIssue
+	fix   func(lines []string) []string
+}
+
+func lineLengthRule(max int) lintRule {
+	return lintRule{check: func(lines []string) []lintIssue {
+		var issues []lintIssue
+		for i, line := range lines {
+			if n := len([]rune(line)); n > max {
+				issues = append(issues, lintIssue{i + 1, fmt.Sprintf("line is %d characters long (max %d)", n, max)})
+			}
+		}
+		return issues
+	}}
+}
+
+var trailingWhitespaceRule = lintRule{
+	check: func(lines []string) []lintIssue {
+		var issues []lintIssue
+		for i, line := range lines {
+			if strings.TrimRight(line, " \t") != line {
+				issues = append(issues, lintIssue{i + 1, "trailing whitespace"})
+			}
+		}
+		return issues
+	},
+	fix: func(lines []string) []string {
+		fixed := make([]string, len(lines))
+		for i, line := range lines {
+			fixed[i] = strings.TrimRight(line, " \t")
+		}
+		return fixed
+	},
+}
+
+var mixedIndentRule = lintRule{check: func(lines []string) []lintIssue {
+	firstTab, firstSpace := 0, 0
+	for i, line := range lines {
+		if firstTab == 0 && strings.HasPrefix(line, "\t") {
+			firstTab = i + 1
+		}
+		if firstSpace == 0 && strings.HasPrefix(line, " ") {
+			firstSpace = i + 1
+		}
+	}
+	if firstTab == 0 || firstSpace == 0 {
+		return nil
+	}
+	return []lintIssue{{max(firstTab, firstSpace), "indentation mixes tabs and spaces"}}
+}}
+
+var blankLinesRule = lintRule{
+	check: func(lines []string) []lintIssue {
+		var issues []lintIssue
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "" && strings.TrimSpace(lines[i-1]) == "" && i+1 < len(lines) {
+				issues = append(issues, lintIssue{i + 1, "multiple consecutive blank lines"})
+			}
+		}
+		return issues
+	},
+	fix: func(lines []string) []string {
+		var fixed []string
+		for i, line := range lines {
+			if i > 0 && i+1 < len(lines) && strings.TrimSpace(line) == "" && strings.TrimSpace(lines[i-1]) == "" {
+				continue
+			}
+			fixed = append(fixed, line)
+		}
+		return fixed
+	},
+}
+
+func lintRules(maxLineLength int) []lintRule {
+	rules := []lintRule{trailingWhitespaceRule, mixedIndentRule, blankLinesRule}
+	if maxLineLength > 0 {
+		rules = append(rules, lineLengthRule(maxLineLength))
+	}
+	return rules
+}
+
+// lintNotes runs the prose rules over every note, applying the mechanical
+// fixes when fix is set. It exits non-zero if any issue remains.
+func lintNotes(zettelHome string, maxLineLength int, fix bool) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	rules := lintRules(maxLineLength)
+	remaining, fixed := 0, 0
+	for _, id := range ids {
+		path := filepath.Join(zettelHome, id+noteExtension)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+
+		lines := strings.Split(string(content), "\n")
+		changed := false
+		for _, rule := range rules {
+			issues := rule.check(lines)
+			if len(issues) == 0 {
+				continue
+			}
+			if fix && rule.fix != nil {
+				lines = rule.fix(lines)
+				changed = true
+				continue
+			}
+			for _, issue := range issues {
+				fmt.Printf("%s:%d: %s\n", id, issue.line, issue.message)
+			}
+			remaining += len(issues)
+		}
+
+		if changed {
+			if err := stageWrite(zettelHome, path, []byte(strings.Join(lines, "\n")), "lint fixes"); err != nil {
+				return fmt.Errorf("Error writing note: %w", err)
+			}
+			if !dryRun {
+				fmt.Println("Fixed", id)
+			}
+			fixed++
+		}
+	}
+
+	if fixed > 0 && !dryRun {
+		recordChange(zettelHome, fmt.Sprintf("lint %d notes", fixed))
+	}
+	if remaining > 0 {
+		return errReported
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   lintRule
+		note   string
+		issues []int
+		fixed  string
+	}{
+		{"trailing whitespace", trailingWhitespaceRule, "# A \nok\nbody\t\n", []int{1, 3}, "# A\nok\nbody\n"},
+		{"trailing whitespace clean", trailingWhitespaceRule, "# A\nbody\n", nil, "# A\nbody\n"},
+		{"mixed indentation", mixedIndentRule, "- a\n\t- b\n  - c\n", []int{3}, ""},
+		{"tabs only", mixedIndentRule, "- a\n\t- b\n\t- c\n", nil, ""},
+		{"blank lines", blankLinesRule, "# A\n\n\n\nbody\n\ntail\n", []int{3, 4}, "# A\n\nbody\n\ntail\n"},
+		{"trailing newline", blankLinesRule, "# A\n\n", nil, "# A\n\n"},
+		{"line length", lineLengthRule(5), "short\ntoo long\nåäöåä\n", []int{2}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(tt.note, "\n")
+			var got []int
+			for _, issue := range tt.rule.check(lines) {
+				got = append(got, issue.line)
+			}
+			if !slices.Equal(got, tt.issues) {
+				t.Errorf("issues on lines %v, want %v", got, tt.issues)
+			}
+			if tt.rule.fix == nil {
+				return
+			}
+			if fixed := strings.Join(tt.rule.fix(lines), "\n"); fixed != tt.fixed {
+				t.Errorf("fixed to %q, want %q", fixed, tt.fixed)
+			}
+		})
+	}
+}
+
+func TestLintFix(t *testing.T) {
+	home := testVault(t, map[string]string{"a": "# A  \n\n\n\nbody\n"})
+
+	if _, code := runZettel(t, home, "--dry-run", "doctor", "--lint", "--fix"); code != 0 {
+		t.Errorf("doctor --lint --fix --dry-run exited %d", code)
+	}
+	if got := readTestNote(t, home, "a"); got != "# A  \n\n\n\nbody\n" {
+		t.Errorf("under --dry-run a = %q, want it unchanged", got)
+	}
+
+	if _, code := runZettel(t, home, "--yes", "doctor", "--lint", "--fix"); code != 0 {
+		t.Errorf("doctor --lint --fix exited %d", code)
+	}
+	if got, want := readTestNote(t, home, "a"), "# A\n\nbody\n"; got != want {
+		t.Errorf("a = %q, want %q", got, want)
+	}
+	if _, code := runZettel(t, home, "doctor", "--lint"); code != 0 {
+		t.Errorf("doctor --lint after fixing exited %d", code)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// sortNoteIDs orders ids by name, by the creation time encoded in the ID or
+// by file modification time. Notes without a timestamp ID sort after the
+// others when ordering by creation time.
+func sortNoteIDs(zettelHome string, ids []string, by string) error {
+	switch by {
+	case "name":
+		sort.Strings(ids)
+	case "created":
+		sort.SliceStable(ids, func(i, j int) bool {
+			ti, oki := noteCreated(ids[i])
+			tj, okj := noteCreated(ids[j])
+			if oki != okj {
+				return oki
+			}
+			if !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+			return ids[i] < ids[j]
+		})
+	case "modified":
+		modTimes := make(map[string]time.Time, len(ids))
+		for _, id := range ids {
+			info, err := os.Stat(filepath.Join(zettelHome, id+noteExtension))
+			if err != nil {
+				return err
+			}
+			modTimes[id] = info.ModTime()
+		}
+		sort.SliceStable(ids, func(i, j int) bool {
+			if !modTimes[ids[i]].Equal(modTimes[ids[j]]) {
+				return modTimes[ids[i]].Before(modTimes[ids[j]])
+			}
+			return ids[i] < ids[j]
+		})
+	default:
+		return fmt.Errorf("unknown sort order %q", by)
+	}
+	return nil
+}
+
+// listOptions selects, orders and formats the notes printed by listNotes.
+type listOptions struct {
+	sortBy  string
+	reverse bool
+	tags    []string
+	anyTag  bool
+	verbose bool
+	// includeArchived also lists the notes in the archive directory.
+	includeArchived bool
+	// pinnedFirst moves the pinned notes ahead of the others, keeping the
+	// sort order within each group.
+	pinnedFirst bool
+}
+
+const wordsPerMinute = 200
+
+// readingMinutes estimates the reading time of a note, rounded up.
+func readingMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+func listNotes(zettelHome string, opts listOptions) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+	if opts.includeArchived {
+		archived, err := listArchivedIDs(zettelHome)
+		if err != nil {
+			return fmt.Errorf("Error listing notes: %w", err)
+		}
+		ids = append(ids, archived...)
+	}
+
+	if len(opts.tags) > 0 {
+		matching := ids[:0]
+		for _, id := range ids {
+			content, err := readNote(zettelHome, id)
+			if err != nil {
+				return fmt.Errorf("Error reading note: %w", err)
+			}
+			if hasTags(noteTags(content), opts.tags, opts.anyTag) {
+				matching = append(matching, id)
+			}
+		}
+		ids = matching
+	}
+
+	if err := sortNoteIDs(zettelHome, ids, opts.sortBy); err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+	if opts.reverse {
+		for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+			ids[i], ids[j] = ids[j], ids[i]
+		}
+	}
+	if opts.pinnedFirst {
+		pinned, err := readPinned(zettelHome)
+		if err != nil {
+			return fmt.Errorf("Error reading pinned notes: %w", err)
+		}
+		isPinned := map[string]bool{}
+		for _, id := range pinned {
+			isPinned[id] = true
+		}
+		sort.SliceStable(ids, func(i, j int) bool { return isPinned[ids[i]] && !isPinned[ids[j]] })
+	}
+
+	words := map[string]int{}
+	if opts.verbose {
+		for _, id := range ids {
+			content, err := readNote(zettelHome, id)
+			if err != nil {
+				return fmt.Errorf("Error reading note: %w", err)
+			}
+			words[id] = countWords(content)
+		}
+	}
+
+	if jsonOutput {
+		type noteResult struct {
+			ID       string `json:"id"`
+			Filename string `json:"filename"`
+			Words    *int   `json:"words,omitempty"`
+			Minutes  *int   `json:"reading_minutes,omitempty"`
+		}
+		results := []noteResult{}
+		for _, id := range ids {
+			result := noteResult{ID: id, Filename: id + noteExtension}
+			if opts.verbose {
+				n, minutes := words[id], readingMinutes(words[id])
+				result.Words, result.Minutes = &n, &minutes
+			}
+			results = append(results, result)
+		}
+		return printJSON(results)
+	}
+
+	if opts.verbose {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, id := range ids {
+			fmt.Fprintf(w, "%s\t%d words\t%d min\n", colorize(colorFile, id+noteExtension), words[id], readingMinutes(words[id]))
+		}
+		w.Flush()
+		return nil
+	}
+
+	for _, id := range ids {
+		fmt.Println(colorize(colorFile, id+noteExtension))
+	}
+	return nil
+}
@@ -1,187 +1,1864 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
-	defaultHome   = "zettelkasten"
-	noteExtension = ".md"
+	defaultHome          = "zettelkasten"
+	defaultNoteExtension = ".md"
 )
 
+// noteExtension is the extension of note files, set from the config file.
+var noteExtension = defaultNoteExtension
+
+// Exit statuses, documented at the end of printUsage.
+const (
+	exitError    = 1 // any other failure
+	exitUsage    = 2 // missing or invalid arguments, as for unknown flags
+	exitNotFound = 3 // no such note, alias, heading or tag
+	exitNoMatch  = 4 // search --fail-empty found nothing
+)
+
+// Commands return a usageError, notFoundError or noMatchError to have main
+// exit with the matching status, and any other error to exit with
+// exitError. main prints the message of every error that has one.
+type (
+	usageError    struct{ msg string }
+	notFoundError struct{ msg string }
+	noMatchError  struct{ msg string }
+)
+
+func (e usageError) Error() string    { return e.msg }
+func (e notFoundError) Error() string { return e.msg }
+func (e noMatchError) Error() string  { return e.msg }
+
+func usagef(format string, a ...any) error    { return usageError{fmt.Sprintf(format, a...)} }
+func notFoundf(format string, a ...any) error { return notFoundError{fmt.Sprintf(format, a...)} }
+
+// errReported is returned by commands that printed why they failed, such
+// as doctor after listing broken links.
+var errReported = errors.New("")
+
+// exitStatus returns the status main exits with after err.
+func exitStatus(err error) int {
+	switch {
+	case errors.As(err, new(usageError)):
+		return exitUsage
+	case errors.As(err, new(notFoundError)):
+		return exitNotFound
+	case errors.As(err, new(noMatchError)):
+		return exitNoMatch
+	}
+	return exitError
+}
+
+// jsonOutput selects JSON output for the commands that support it.
+var jsonOutput bool
+
+// noEdit is set by the --no-edit flag.
+var noEdit bool
+
+// assumeYes is set by the --yes flag and answers every confirmation.
+var assumeYes bool
+
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+// commandAliases maps the short and long option style of the original
+// zettel.go dispatcher onto subcommands.
+var commandAliases = map[string]string{
+	"-n": "new", "--new": "new",
+	"-o": "open", "--open": "open",
+	"-l": "list", "--list": "list",
+	"-t": "tags", "--tags": "tags",
+	"-i": "index", "--index": "index",
+	"-V": "version", "--version": "version",
+	"-h": "help", "--help": "help",
+	"--completion": "completion",
+}
+
 func main() {
+	err := run()
+	// -h after a command prints its flags and succeeds.
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if msg := err.Error(); msg != "" {
+		fmt.Println(msg)
+	}
+	os.Exit(exitStatus(err))
+}
+
+// run runs the command in os.Args.
+func run() error {
 	if len(os.Args) < 2 {
 		printUsage()
-		os.Exit(1)
+		return usageError{}
+	}
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		return err
 	}
+	os.Args = append(os.Args[:1], args...)
+	if len(os.Args) < 2 {
+		printUsage()
+		return usageError{}
+	}
+	if command, ok := commandAliases[os.Args[1]]; ok {
+		os.Args[1] = command
+	}
+	// Commands without flags of their own would take a --dry-run after them
+	// as an argument and go ahead.
+	if slices.Contains(os.Args[2:], "--dry-run") {
+		dryRun = true
+	}
+	if err := checkDryRun(os.Args[1]); err != nil {
+		return err
+	}
+
+	if path, err := configPath(); err == nil {
+		if cfg, err = loadConfig(path); err != nil && os.Args[1] != "config" {
+			return fmt.Errorf("Error reading config %s:\n%w", path, err)
+		}
+	}
+	noteExtension = cfg.NoteExtension
 
 	zettelHome, err := getZettelHome()
 	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+		return fmt.Errorf("Error: %w", err)
 	}
 
 	switch os.Args[1] {
+	case "help":
+		printUsage()
+	case "version":
+		fmt.Println("zettel", version)
+	case "where":
+		return printWhere()
+	case "shell":
+		return runShell(zettelHome)
+	case "__complete-ids":
+		printCompletionIDs(zettelHome)
+	case "__complete-tags":
+		printCompletionTags(zettelHome)
+	case "completion":
+		shell := "bash"
+		if len(os.Args) > 2 {
+			shell = os.Args[2]
+		}
+		return printCompletion(shell)
 	case "new":
-		createNewNote(zettelHome)
+		fs := newFlagSet("new")
+		verbose := fs.Bool("verbose", false, "describe the created note on stderr")
+		frontmatter := fs.Bool("frontmatter", false, "start the note with YAML frontmatter")
+		tmpl := fs.String("template", "", "start from the `name` template in the .templates directory")
+		stdin := fs.Bool("stdin", false, "read the note body from stdin instead of opening the editor")
+		clipboard := fs.Bool("clipboard", false, "start the note body with the clipboard contents")
+		rawTitle := fs.Bool("raw-title", false, "name the note after the title with only its spaces replaced by dashes")
+		linkFrom := fs.String("link-from", "", "link the note with this `ID` to the new note")
+		backlink := fs.Bool("backlink", false, "with --link-from, also link the new note back")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if *stdin && *clipboard {
+			return usagef("--stdin and --clipboard cannot be combined")
+		}
+		if *backlink && *linkFrom == "" {
+			return usagef("--backlink needs --link-from")
+		}
+		if *linkFrom != "" {
+			*linkFrom = noteID(*linkFrom)
+		}
+		return createNewNote(zettelHome, newNoteOptions{
+			title:       strings.Join(args, " "),
+			template:    *tmpl,
+			frontmatter: *frontmatter,
+			verbose:     *verbose,
+			stdin:       *stdin,
+			clipboard:   *clipboard,
+			rawTitle:    *rawTitle,
+			linkFrom:    *linkFrom,
+			backlink:    *backlink,
+		})
+	case "today":
+		fs := newFlagSet("today")
+		date := fs.String("date", "", "open the journal of `YYYY-MM-DD` instead of today")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		day := time.Now()
+		if *date != "" {
+			var err error
+			if day, err = time.ParseInLocation("2006-01-02", *date, time.Local); err != nil {
+				return usagef("Invalid date: %v", *date)
+			}
+		}
+		return openDailyNote(zettelHome, day)
 	case "edit":
 		if len(os.Args) < 3 {
-			fmt.Println("Please provide a note ID")
-			os.Exit(1)
+			return usagef("Please provide a note ID")
+		}
+		return editNote(zettelHome, noteID(os.Args[2]))
+	case "touch":
+		fs := newFlagSet("touch")
+		date := fs.String("date", "", "set the time to `YYYY-MM-DD[ HH:MM]` instead of now")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		t := time.Now()
+		if *date != "" {
+			var err error
+			if t, err = time.ParseInLocation("2006-01-02 15:04", *date, time.Local); err != nil {
+				if t, err = time.ParseInLocation("2006-01-02", *date, time.Local); err != nil {
+					return usagef("Invalid date: %v", *date)
+				}
+			}
 		}
-		editNote(zettelHome, os.Args[2])
+		return touchNote(zettelHome, noteID(args[0]), t)
+	case "list":
+		fs := newFlagSet("list")
+		sortBy := fs.String("sort", "name", "sort `order`: name, created or modified")
+		reverse := fs.Bool("reverse", false, "reverse the sort order")
+		var tags stringList
+		fs.Var(&tags, "tag", "only list notes tagged `name` (repeatable)")
+		anyTag := fs.Bool("any", false, "match notes with any of the tags instead of all")
+		verbose := fs.Bool("verbose", false, "show word counts and reading times")
+		includeArchived := fs.Bool("include-archived", false, "also list archived notes")
+		pinnedFirst := fs.Bool("pinned-first", false, "list pinned notes before the others")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		return listNotes(zettelHome, listOptions{
+			sortBy:          *sortBy,
+			reverse:         *reverse,
+			tags:            tags,
+			anyTag:          *anyTag,
+			verbose:         *verbose,
+			includeArchived: *includeArchived,
+			pinnedFirst:     *pinnedFirst,
+		})
 	case "search":
-		if len(os.Args) < 3 {
-			fmt.Println("Please provide a search query")
-			os.Exit(1)
+		fs := newFlagSet("search")
+		replace := fs.String("replace", "", "replace every match with `text`")
+		useRegex := fs.Bool("regex", false, "treat the query as a regular expression when replacing")
+		ignoreCase := fs.Bool("i", false, "match case-insensitively")
+		includeArchived := fs.Bool("include-archived", false, "also search archived notes")
+		filesOnly := fs.Bool("files-only", false, "print only the IDs of matching notes")
+		context := fs.Int("C", 0, "print `N` lines of context around matches")
+		var excludeTags stringList
+		fs.Var(&excludeTags, "exclude-tag", "leave out notes tagged `name` (repeatable)")
+		rebuild := fs.Bool("rebuild", false, "rebuild the search index first")
+		titleOnly := fs.Bool("title-only", false, "only match note titles, or filename slugs of untitled notes")
+		count := fs.Bool("count", false, "print only the number of matching notes")
+		fs.BoolVar(count, "c", false, "shorthand for --count")
+		countLines := fs.Bool("count-lines", false, "print only the number of matching lines")
+		failEmpty := fs.Bool("fail-empty", false, "exit with status 4 when nothing matches")
+		includeMeta := fs.Bool("include-meta", false, "also search the frontmatter of notes")
+		since := fs.String("since", "", "only search notes created since `when`: Nd, Nw, Nm or YYYY-MM-DD")
+		until := fs.String("until", "", "only search notes created before `when`, including a whole YYYY-MM-DD")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a search query")
+		}
+		var sinceTime, untilTime time.Time
+		if *since != "" {
+			t, err := parseSince(*since, time.Now())
+			if err != nil {
+				return fmt.Errorf("Error in --since: %w", err)
+			}
+			sinceTime = t
+		}
+		if *until != "" {
+			t, err := parseUntil(*until, time.Now())
+			if err != nil {
+				return fmt.Errorf("Error in --until: %w", err)
+			}
+			untilTime = t
+		}
+		replacing := false
+		fs.Visit(func(f *flag.Flag) { replacing = replacing || f.Name == "replace" })
+		if *rebuild {
+			if _, err := buildSearchIndex(zettelHome); err != nil {
+				return fmt.Errorf("Error building search index: %w", err)
+			}
+		}
+		if replacing {
+			return replaceInNotes(zettelHome, args[0], *replace, *useRegex, *ignoreCase)
+		} else {
+			return searchNotes(zettelHome, args[0], searchOptions{
+				ignoreCase:      *ignoreCase,
+				includeArchived: *includeArchived,
+				filesOnly:       *filesOnly,
+				context:         max(*context, 0),
+				excludeTags:     excludeTags,
+				titleOnly:       *titleOnly,
+				count:           *count,
+				countLines:      *countLines,
+				failEmpty:       *failEmpty,
+				includeMeta:     *includeMeta,
+				since:           sinceTime,
+				until:           untilTime,
+			})
 		}
-		searchNotes(zettelHome, os.Args[2])
+	case "index-build":
+		return rebuildSearchIndex(zettelHome)
+	case "find":
+		fs := newFlagSet("find")
+		ignoreCase := fs.Bool("i", false, "match case-insensitively")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		return findNotes(zettelHome, query, *ignoreCase)
 	case "link":
+		fs := newFlagSet("link")
+		replace := fs.String("replace", "", "rewrite the existing link to `ID` instead of appending")
+		var bidirectional bool
+		fs.BoolVar(&bidirectional, "bidirectional", false, "also link dest back to src")
+		fs.BoolVar(&bidirectional, "b", false, "shorthand for --bidirectional")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 2 {
+			return usagef("Please provide source and target IDs")
+		}
+		if *replace != "" {
+			return replaceLink(zettelHome, noteID(args[0]), noteID(*replace), noteID(args[1]))
+		} else {
+			return linkNotes(zettelHome, noteID(args[0]), noteID(args[1]), bidirectional)
+		}
+	case "delete":
+		fs := newFlagSet("delete")
+		force := fs.Bool("force", false, "delete without asking for confirmation")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return deleteNote(zettelHome, noteID(args[0]), *force)
+	case "rename":
+		args, err := parseFlags(newFlagSet("rename"), os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 2 {
+			return usagef("Please provide a note ID and a new title")
+		}
+		return renameNote(zettelHome, noteID(args[0]), strings.Join(args[1:], " "))
+	case "merge":
+		fs := newFlagSet("merge")
+		keepSource := fs.Bool("keep-source", false, "keep the source note instead of deleting it")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 2 {
+			return usagef("Please provide the source and target note IDs")
+		}
+		return mergeNotes(zettelHome, noteID(args[0]), noteID(args[1]), *keepSource)
+	case "split":
+		fs := newFlagSet("split")
+		fromHeading := fs.String("from-heading", "", "split off the section under this heading")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return splitNote(zettelHome, noteID(args[0]), *fromHeading)
+	case "open":
+		fs := newFlagSet("open")
+		ignoreCase := fs.Bool("i", false, "match case-insensitively")
+		fuzzy := fs.Bool("fuzzy", false, "rank notes by approximate match against their IDs")
+		titleOnly := fs.Bool("title-only", false, "only match note titles, or filename slugs of untitled notes")
+		all := fs.Bool("all", false, "open every matching note instead of choosing one")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a search query")
+		}
+		return openNotes(zettelHome, args[0], openOptions{
+			ignoreCase: *ignoreCase,
+			fuzzy:      *fuzzy,
+			titleOnly:  *titleOnly,
+			all:        *all,
+		})
+	case "open-id":
+		if len(os.Args) < 3 {
+			return usagef("Please provide a note ID")
+		}
+		return openByID(zettelHome, os.Args[2])
+	case "recent":
+		fs := newFlagSet("recent")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		n := 10
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return usagef("Invalid number of notes: %v", args[0])
+			}
+		}
+		return listRecentNotes(zettelHome, n)
+	case "log":
+		fs := newFlagSet("log")
+		since := fs.String("since", "7d", "list notes created since `when`: Nd, Nw, Nm or YYYY-MM-DD")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		t, err := parseSince(*since, time.Now())
+		if err != nil {
+			return fmt.Errorf("Error in --since: %w", err)
+		}
+		return logNotes(zettelHome, t)
+	case "random":
+		fs := newFlagSet("random")
+		tag := fs.String("tag", "", "only choose among notes tagged `name`")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		return openRandomNote(zettelHome, *tag)
+	case "back":
+		return goBack(zettelHome)
+	case "backlinks":
+		if len(os.Args) < 3 {
+			return usagef("Please provide a note ID")
+		}
+		return printBacklinks(zettelHome, noteID(os.Args[2]))
+	case "follow":
+		args, err := parseFlags(newFlagSet("follow"), os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		n := 0
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return usagef("Invalid link number: %v", args[1])
+			}
+		}
+		return followLink(zettelHome, noteID(args[0]), n)
+	case "links":
+		args, err := parseFlags(newFlagSet("links"), os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return printOutgoingLinks(zettelHome, noteID(args[0]))
+	case "progress":
+		id := ""
+		if len(os.Args) > 2 {
+			id = noteID(os.Args[2])
+		}
+		return showProgress(zettelHome, id)
+	case "stats":
+		return printStats(zettelHome)
+	case "duplicates":
+		return findDuplicates(zettelHome)
+	case "dedup":
+		fs := newFlagSet("dedup")
+		deleteDupes := fs.Bool("delete-dupes", false, "keep the oldest of each group and delete the rest after asking")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		return dedupNotes(zettelHome, *deleteDupes)
+	case "orphans":
+		fs := newFlagSet("orphans")
+		includeIndex := fs.Bool("include-index", false, "also report index notes")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		return printOrphans(zettelHome, *includeIndex)
+	case "related":
+		fs := newFlagSet("related")
+		useLinks := fs.Bool("links", false, "also score notes by shared link neighbours")
+		linkIt := fs.Int("link-it", 0, "append links to the top `N` related notes")
+		limit := fs.Int("n", 10, "show at most `N` notes")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return showRelated(zettelHome, noteID(args[0]), *useLinks, *limit, *linkIt)
+	case "suggest":
+		fs := newFlagSet("suggest")
+		link := fs.Int("link", 0, "after confirmation, link to the top `N` suggestions")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return suggestLinks(zettelHome, noteID(args[0]), *link)
+	case "export":
+		fs := newFlagSet("export")
+		format := fs.String("format", "rss", "feed `format`: rss or atom")
+		var out string
+		fs.StringVar(&out, "out", "", "write to `file` instead of stdout")
+		fs.StringVar(&out, "o", "", "shorthand for --out")
+		title := fs.String("title", "Zettelkasten", "feed `title`")
+		baseURL := fs.String("base-url", "", "`URL` prefix for note links")
+		limit := fs.Int("n", 20, "include the `N` most recent notes")
+		excerptLength := fs.Int("excerpt", cfg.ExcerptLength, "excerpt length in `characters`")
+		depth := fs.Int("depth", 1, "follow links up to `N` hops when exporting a note")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return exportBundle(zettelHome, noteID(args[0]), out, *depth)
+		} else {
+			return exportFeed(zettelHome, *format, out, *title, *baseURL, *limit, *excerptLength)
+		}
+	case "next-id":
+		fs := newFlagSet("next-id")
+		showPath := fs.Bool("path", false, "print the path of the note file instead")
+		rawTitle := fs.Bool("raw-title", false, "only replace the spaces of the title with dashes, as new --raw-title does")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		id := availableNoteID(zettelHome, newNoteID(strings.Join(args, " "), *rawTitle))
+		if *showPath {
+			fmt.Println(notePath(zettelHome, id))
+		} else {
+			fmt.Println(id)
+		}
+	case "export-obsidian":
+		fs := newFlagSet("export-obsidian")
+		titleLinks := fs.Bool("title-links", false, "name notes after their titles and link to them by title")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide an output directory")
+		}
+		return exportObsidian(zettelHome, args[0], *titleLinks)
+	case "import":
+		fs := newFlagSet("import")
+		move := fs.Bool("move", false, "delete the original files after importing them")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide the markdown files to import")
+		}
+		return importNotes(zettelHome, args, *move)
+	case "show":
+		fs := newFlagSet("show")
+		raw := fs.Bool("raw", false, "print the markdown without styling")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return showNote(zettelHome, noteID(args[0]), *raw)
+	case "render":
+		fs := newFlagSet("render")
+		var out string
+		fs.StringVar(&out, "out", "", "write to `file` instead of stdout")
+		fs.StringVar(&out, "o", "", "shorthand for --out")
+		css := fs.String("css", "", "inline the stylesheet at `path`")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return renderNote(zettelHome, noteID(args[0]), out, *css)
+	case "publish":
+		fs := newFlagSet("publish")
+		css := fs.String("css", "", "inline the stylesheet at `path` in every page")
+		includeArchived := fs.Bool("include-archived", false, "also publish archived notes")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide an output directory")
+		}
+		return publishSite(zettelHome, args[0], *css, *includeArchived)
+	case "backup":
+		dest := ""
+		if len(os.Args) > 2 {
+			dest = os.Args[2]
+		}
+		return backupVault(zettelHome, dest)
+	case "pin", "unpin":
+		if len(os.Args) < 3 {
+			return usagef("Please provide a note ID")
+		}
+		return pinNote(zettelHome, noteID(os.Args[2]), os.Args[1] == "unpin")
+	case "pinned":
+		return listPinned(zettelHome)
+	case "alias":
+		fs := newFlagSet("alias")
+		list := fs.Bool("list", false, "list the aliases")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		switch {
+		case *list:
+			return listAliases(zettelHome)
+		case len(args) < 2:
+			return usagef("Please provide a note ID and an alias")
+		default:
+			return addAlias(zettelHome, noteID(args[0]), strings.Join(args[1:], " "))
+		}
+	case "unalias":
+		if len(os.Args) < 3 {
+			return usagef("Please provide an alias")
+		}
+		return addAlias(zettelHome, "", strings.Join(os.Args[2:], " "))
+	case "archive", "unarchive":
+		if len(os.Args) < 3 {
+			return usagef("Please provide a note ID")
+		}
+		return archiveNote(zettelHome, noteID(os.Args[2]), os.Args[1] == "unarchive")
+	case "vault":
+		switch {
+		case len(os.Args) == 3 && os.Args[2] == "list":
+			return listVaults()
+		case len(os.Args) == 5 && os.Args[2] == "add":
+			return addVault(os.Args[3], os.Args[4])
+		default:
+			return usagef("Usage: zettel vault list | zettel vault add <name> <path>")
+		}
+	case "config":
+		path, err := configPath()
+		if err != nil {
+			return fmt.Errorf("Error: %w", err)
+		}
+		if len(os.Args) > 3 {
+			path = os.Args[3]
+		}
+		switch {
+		case len(os.Args) > 2 && os.Args[2] == "defaults":
+			printDefaultConfig()
+		case len(os.Args) > 2 && os.Args[2] == "check":
+			return checkConfig(path)
+		default:
+			fmt.Println(path)
+		}
+	case "outline":
+		fs := newFlagSet("outline")
+		all := fs.Bool("all", false, "list every note's title and top-level headings")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if *all {
+			return printAllOutlines(zettelHome)
+		}
+		if len(args) < 1 {
+			return usagef("Please provide a note ID")
+		}
+		return printOutline(zettelHome, noteID(args[0]))
+	case "index":
+		fs := newFlagSet("index")
+		title := fs.String("title", "", "title the index note `title` instead of after the tags")
+		args, err := parseFlags(fs, os.Args[2:])
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 {
+			return usagef("Please provide at least one tag")
+		}
+		var tags []string
+		for _, tag := range args {
+			tags = append(tags, strings.TrimPrefix(tag, "#"))
+		}
+		return createIndexNote(zettelHome, *title, tags)
+	case "watch-index":
 		if len(os.Args) < 4 {
-			fmt.Println("Please provide source and target IDs")
-			os.Exit(1)
+			return usagef("Usage: zettel watch-index <ID> <tag>...")
+		}
+		var tags []string
+		for _, tag := range os.Args[3:] {
+			tags = append(tags, strings.TrimPrefix(tag, "#"))
+		}
+		return watchIndex(zettelHome, noteID(os.Args[2]), tags)
+	case "reindex":
+		if len(os.Args) < 3 {
+			return usagef("Please provide a note ID")
+		}
+		return reindexNote(zettelHome, noteID(os.Args[2]))
+	case "graph":
+		fs := newFlagSet("graph")
+		format := fs.String("format", "dot", "output `format`: dot or json")
+		highlight := fs.String("highlight", "", "focus note `ID`; other notes get their link distance from it")
+		tag := fs.String("tag", "", "only include notes tagged `name`")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		return printGraph(zettelHome, *format, noteID(*highlight), strings.TrimPrefix(*tag, "#"))
+	case "tags":
+		fs := newFlagSet("tags")
+		hidePlaceholder := fs.Bool("no-placeholder", cfg.HidePlaceholder, "omit the placeholder tag seeded into new notes")
+		count := fs.Bool("count", false, "show how many notes use each tag, most used first")
+		tree := fs.Bool("tree", false, "print nested tags as an indented hierarchy")
+		byNote := fs.Bool("by-note", false, "print every note with its own tags")
+		stats := fs.Bool("stats", false, "show the notes, words and average words of each tag, most used first")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		return listTags(zettelHome, tagListOptions{
+			hidePlaceholder: *hidePlaceholder,
+			count:           *count,
+			tree:            *tree,
+			byNote:          *byNote,
+			stats:           *stats,
+		})
+	case "tag":
+		if len(os.Args) < 3 || !slices.Contains([]string{"rename", "add", "remove"}, os.Args[2]) {
+			return usagef("Usage: zettel tag rename <old> <new> | add <ID> <tag> | remove <ID> <tag>")
+		}
+		fs := newFlagSet("tag " + os.Args[2])
+		var note string
+		if os.Args[2] == "rename" {
+			fs.StringVar(&note, "note", "", "only rename the tag in the note with this `ID`")
+		}
+		args, err := parseFlags(fs, os.Args[3:])
+		if err != nil {
+			return err
+		}
+		switch {
+		case os.Args[2] != "rename" && len(args) < 2:
+			return usagef("Please provide a note ID and a tag")
+		case len(args) < 2:
+			return usagef("Please provide the old and new tag names")
+		case os.Args[2] == "rename":
+			if note != "" {
+				note = noteID(note)
+			}
+			return renameTagInNotes(zettelHome, args[0], args[1], note)
+		default:
+			return tagNote(zettelHome, noteID(args[0]), args[1], os.Args[2] == "remove")
+		}
+	case "clean":
+		fs := newFlagSet("clean")
+		minWords := fs.Int("min-words", 1, "list notes with fewer than `N` words besides headings and tags")
+		remove := fs.Bool("delete", false, "delete the listed notes after asking, removing links to them")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		return cleanNotes(zettelHome, *minWords, *remove)
+	case "doctor":
+		fs := newFlagSet("doctor")
+		trimTagme := fs.Bool("trim-tagme", false, "remove the placeholder tag from notes that have other tags")
+		lint := fs.Bool("lint", false, "check notes for prose and whitespace issues")
+		maxLineLength := fs.Int("max-line-length", 0, "flag lines longer than `N` characters when linting (0 disables)")
+		names := fs.Bool("names", false, "check note filenames against the ID scheme")
+		fix := fs.Bool("fix", false, "fix whitespace issues found when linting and rename misnamed notes")
+		if _, err := parseFlags(fs, os.Args[2:]); err != nil {
+			return err
+		}
+		broken, err := reportBrokenLinks(zettelHome)
+		if err != nil {
+			return fmt.Errorf("Error reading notes: %w", err)
+		}
+		if err := trimPlaceholderTag(zettelHome, *trimTagme); err != nil {
+			return err
+		}
+		if *fix && (*names || *lint) && !dryRun && !confirm("Fix notes in place?") {
+			fmt.Println("Only reporting issues")
+			*fix = false
+		}
+		if *names {
+			return checkNoteNames(zettelHome, *fix)
+		}
+		if *lint {
+			return lintNotes(zettelHome, *maxLineLength, *fix)
+		}
+		if broken > 0 {
+			return errReported
 		}
-		linkNotes(zettelHome, os.Args[2], os.Args[3])
 	default:
 		printUsage()
-		os.Exit(1)
+		return usageError{}
 	}
+	return nil
 }
 
 func printUsage() {
 	fmt.Println(`Zettelkasten CLI
 
 Usage:
-  zettel new                Create new note
+  zettel [global flags] <command> [args]
+
+  zettel help               Show this help (-h, --help)
+  zettel version            Print the version (-V, --version)
+  zettel where              Print the notes directory, and on stderr whether
+                            it comes from --dir, --vault, ZETTEL_HOME, the
+                            config file or the default
+  zettel completion [shell] Print a bash, zsh or fish completion script
+                            (default: bash)
+  zettel shell              Run commands repeatedly on the same notes
+                            directory; "history" lists them, "!N" reruns one
+                            and "exit" leaves
+  zettel new [title]        Create new note and print its filename (-n),
+                            named after the title in lowercase with accents
+                            transliterated and other characters than
+                            letters and digits made dashes
+    --raw-title             Only replace the spaces of the title with dashes
+    --frontmatter           Start the note with YAML frontmatter
+    --template <name>       Start from .templates/<name>.md in the notes
+                            directory; {{title}}, {{id}} and {{date}} are
+                            substituted
+    --verbose               Also describe the created note on stderr
+    --stdin                 Read the body from stdin instead of opening
+                            the editor
+    --clipboard             Start the body with the clipboard contents
+    --link-from <ID>        Append a link to the new note to note ID, and
+                            print the link on stderr
+    --backlink              Also link the new note back to ID
+  zettel import <file>...   Copy markdown files into new notes named after
+                            their first heading or filename, turning
+                            relative links to imported files or existing
+                            notes into [[links]]
+    --move                  Delete the originals afterwards
+  zettel next-id [title]    Print the ID new would give a note with the
+                            title now, without creating it
+    --path                  Print the path of the note file instead
+    --raw-title             Name it as new --raw-title would
+  zettel today              Open today's journal note, creating it if needed
+    --date <YYYY-MM-DD>     Open the journal of another day
   zettel edit <ID>          Edit existing note
-  zettel search <query>     Search notes
+  zettel show <ID>          Print a note with headings, links and tags
+                            styled on a terminal, through $PAGER if set
+    --raw                   Print the markdown unchanged
+  zettel open <query>       Open a note matching query, choosing from a list
+                            when several match (-o)
+    -i                      Match case-insensitively
+    --fuzzy                 Rank notes by approximate match against their IDs
+    --title-only            Match titles instead of IDs and contents
+    --all                   Open every match, asking first above 10
+  zettel open-id <ID>       Open the note with the ID or alias, or the one
+                            note whose ID starts with ID, which may also be
+                            a copied [[link]]
+  zettel recent [N]         List the N most recently modified notes, newest
+                            first (default: 10)
+  zettel log                List the notes created recently according to the
+                            timestamps in their IDs, newest first
+    --since <when>          Window such as 7d, 2w or 3m, or a date such as
+                            2024-01-31 (default: 7d)
+  zettel touch <ID>         Mark a note as modified now without changing it
+    --date <date>           Use YYYY-MM-DD or "YYYY-MM-DD HH:MM" instead
+  zettel random             Open a random note
+    --tag <name>            Only choose among notes with the tag
+  zettel delete <ID>        Delete a note and remove links to it, asking first
+    --force                 Do not ask for confirmation
+  zettel rename <ID> <title>
+                            Rename a note, keeping its timestamp, and update
+                            links to it
+  zettel merge <source> <target>
+                            Append source to target, combining their tags,
+                            point links to source at target and delete
+                            source, asking first
+    --keep-source           Keep the source note
+  zettel split <ID>         Move part of a note into a new note, linked in its
+                            place; delete all but that part in the editor
+    --from-heading <name>   Move the section under a heading, such as "## Foo"
+  zettel pin <ID>           Add a note to the pinned notes
+  zettel unpin <ID>         Remove a note from the pinned notes
+  zettel pinned             List the pinned notes
+  zettel alias <ID> <name>  Give a note a name that open-id and [[links]]
+                            resolve to
+    --list                  List the aliases
+  zettel unalias <name>     Remove an alias
+  zettel archive <ID>       Move a note into the archive/ subdirectory
+  zettel unarchive <ID>     Move an archived note back
+  zettel back               Reopen the previously edited note
+  zettel list               List notes (-l)
+    --sort <order>          Sort by name, created or modified (default: name)
+    --reverse               Reverse the sort order
+    --tag <name>            Only list notes with the tag (repeatable)
+    --any                   Match any --tag instead of all of them
+    --verbose               Show word counts and reading times
+    --include-archived      Also list archived notes
+    --pinned-first          List pinned notes before the others
+  zettel search <query>     Search notes, printing matching lines by note
+    -i                      Match case-insensitively
+    -C <N>                  Print N lines of context around matches
+    --files-only            Only print the IDs of matching notes
+    --exclude-tag <name>    Leave out notes with the tag (repeatable)
+    --rebuild               Rebuild the search index first
+    --title-only            Only match note titles, or the filename slugs of
+                            notes without a heading
+    -c, --count             Only print the number of matching notes
+    --count-lines           Only print the number of matching lines
+    --fail-empty            Exit with status 4 when nothing matches
+    --since <when>          Only search notes created since a time such as
+                            7d, 2w, 3m or 2024-01-31, by their IDs
+    --until <when>          Only search notes created before such a time,
+                            including the whole day of a date
+    --include-archived      Also search archived notes
+    --include-meta          Also search frontmatter, which is skipped by
+                            default
+    --replace <text>        Replace every match across all notes
+    --regex                 Treat the query as a regular expression
+  zettel index-build        Build the search index in .index, which search
+                            then uses while it is up to date and commands
+                            changing notes keep current
+  zettel find [-i] [query]  Print matching notes for external selectors, one
+                            per line as ID, title, path and excerpt separated
+                            by tabs; \, tab and newlines inside fields are
+                            escaped as \\, \t and \n
   zettel link <src> <dest>  Link two notes
+    --replace <old>         Rewrite the [[old]] link in src to point at dest
+    -b, --bidirectional     Also link dest back to src
+  zettel backlinks <ID>     List notes linking to ID
+  zettel links <ID>         List the links in ID with the file and title of
+                            each target, marking unresolved ones
+  zettel follow <ID> [N]    Open the Nth note ID links to, or list the links
+                            numbering those that resolve and ask for one
+  zettel stats              Show note, word, tag, link and orphan counts
+  zettel duplicates         List titles shared by several notes, ignoring case
+  zettel dedup              List notes with identical bodies, grouped under
+                            the start of their sha256
+    --delete-dupes          Keep the oldest note of each group, point links
+                            to the others at it and delete them, asking first
+  zettel orphans            List notes without links or backlinks
+    --include-index         Also report index notes
+  zettel related <ID>       List notes sharing the most tags with ID
+    --links                 Also count shared link neighbours
+    -n <N>                  Show at most N notes (default: 10)
+    --link-it <N>           Link ID to the top N related notes
+  zettel suggest <ID>       List unlinked notes sharing two or more tags with
+                            ID as candidate links
+    --link <N>              Link ID to the top N after confirmation
+  zettel progress [ID]      Show word count progress towards "goal:" frontmatter
+  zettel export <ID>        Print a note followed by the notes it links to
+    --depth <N>             Follow links up to N hops (default: 1)
+    -o, --out <file>        Write to file
+  zettel export             Print a feed of the most recent notes
+    --format <rss|atom>     Feed format (default: rss)
+    -o, --out <file>        Write the feed to file
+    --title <title>         Feed title
+    --base-url <URL>        Prefix for note links (URL + ID + ".html")
+    -n <N>                  Number of notes (default: 20)
+    --excerpt <N>           Excerpt length in characters (default: 200)
+  zettel export-obsidian <dir>
+                            Copy the notes to dir as an Obsidian vault, with
+                            links by title, slug or alias pointing at
+                            filenames and frontmatter tags without '#'
+    --title-links           Name notes after their titles and link to them
+                            by title, except where titles are shared or
+                            not valid filenames
+  zettel render <ID>        Convert a note to HTML; [[links]] point at
+                            <target>.html and #tags become <span class="tag">
+    -o, --out <file>        Write to file
+    --css <path>            Inline a stylesheet
+  zettel publish <dir>      Render every note to dir as a static HTML site
+                            with backlinks, an index and a tags page
+    --css <path>            Inline a stylesheet in every page
+    --include-archived      Also publish archived notes
+  zettel outline <ID>       Print the heading hierarchy of a note
+    --all                   List every note's title and top-level headings
+  zettel index <tag>...     Create an index note listing the notes with all
+                            the tags between index markers (-i)
+    --title <title>         Title it instead of "Index <tags>"
+  zettel watch-index <ID> <tag>...
+                            Keep a list of links to the notes with all the
+                            tags in note ID between index markers, updating
+                            it as notes change until interrupted
+  zettel reindex <ID>       Rebuild the link list of an index note from the
+                            tags recorded in its start marker
+  zettel graph              Print the link graph, labelled with note titles
+    --format <dot|json>     Output format (default: dot)
+    --highlight <ID>        Mark ID and annotate notes with their distance to it
+    --tag <name>            Only include notes with the tag
+  zettel tags               List all tags (-t)
+    --no-placeholder        Omit the placeholder tag (default: #tagme)
+    --count                 Show how many notes use each tag, most used first
+    --tree                  Print nested tags (#project/zettel) as a hierarchy
+    --by-note               Print every note with its tags, untagged ones too
+    --stats                 Show the number of notes, words and average
+                            words per note of each tag, most used first
+  zettel tag rename <old> <new>
+                            Rename or merge a tag across all notes
+    --note <ID>             Only rename it in one note
+  zettel tag add <ID> <tag> Add a tag to a note, to its frontmatter tags or
+                            its last line of tags if it has them
+  zettel tag remove <ID> <tag>
+                            Remove every occurrence of a tag from a note
+  zettel clean              List stub notes, with no words besides their
+                            frontmatter, headings and tags
+    --min-words <N>         List notes with fewer than N such words instead
+                            (default: 1)
+    --delete                Delete them after asking, removing links to them
+  zettel doctor             Report broken links and notes carrying the
+                            placeholder tag; exits 1 on broken links
+    --trim-tagme            Remove it from notes that have other tags,
+                            after asking
+    --lint                  Check for trailing whitespace, mixed indentation
+                            and repeated blank lines
+    --max-line-length <N>   Also flag lines longer than N characters
+    --names                 Flag filenames not named like YYYYMMDDHHMM-slug.md
+                            and suggest a conforming name
+    --fix                   Fix trailing whitespace and repeated blank lines,
+                            and rename misnamed notes, updating links,
+                            after asking
+  zettel backup [dest]      Write a .tar.gz of the notes directory to dest
+                            (default: current directory)
+  zettel vault list         List the vaults registered in config
+  zettel vault add <name> <path>
+                            Register a vault in config
+  zettel config             Print the config file path
+  zettel config defaults    Print the default configuration
+  zettel config check [file]
+                            Validate the config file
+
+Global flags (before or after the command):
+  --json                    Print JSON from list, search and tags
+  --commit                  Commit changes in git when the notes directory
+                            is a repository (new, link, rename, delete,
+                            tag rename, search --replace)
+  --no-edit                 Create notes with new without opening the editor
+  --dry-run                 Print the files link, delete, rename, merge,
+                            tag, clean --delete, dedup --delete-dupes,
+                            doctor --fix and --trim-tagme and
+                            search --replace would write, rename or delete,
+                            without changing anything
+  --yes                     Answer yes when delete, merge, clean --delete,
+                            dedup --delete-dupes, doctor --fix and
+                            --trim-tagme and search --replace ask for
+                            confirmation; without it they refuse when stdin
+                            is not a terminal
+  --no-color                Do not color the output of search, list and tags
+                            on a terminal
+  -d, --dir <path>          Use the notes directory at path, creating it if
+                            needed; overrides ZETTEL_HOME and --vault (before
+                            the command only)
+  --vault <name>            Use the notes directory of a vault registered
+                            with "vault add", else vaults/<name> next to
+                            the config file; overrides ZETTEL_HOME (before
+                            the command only)
 
 Environment variables:
-  ZETTEL_HOME   Notes directory (default: ~/zettelkasten)
-  EDITOR        Preferred text editor`)
+  ZETTEL_HOME             Notes directory (default: ~/zettelkasten, or on
+                          Linux $XDG_DATA_HOME/zettelkasten when
+                          XDG_DATA_HOME is set and ~/zettelkasten does not
+                          exist)
+  EDITOR                  Preferred text editor, with arguments as in
+                          "code --wait" (default: nano)
+  ZETTEL_PLACEHOLDER_TAG  Tag seeded into new notes (default: tagme)
+  NO_COLOR                Any value turns off colored output
+  PAGER                   Pager show prints notes through on a terminal
+
+Settings are read from zettel/config.toml in the user config directory,
+$XDG_CONFIG_HOME or ~/.config on Linux and ~/Library/Application Support
+on macOS ("zettel config" prints the path, "zettel config defaults" the
+settings); environment variables take precedence over it.
+
+Exit status: 0 on success, 1 on errors, 2 for missing or invalid
+arguments, 3 when a note, alias, heading or tag does not exist, and 4 when
+search --fail-empty finds nothing.
+
+Files matching the gitignore-style patterns in .zettelignore in the notes
+directory, such as "scratch/" or "draft-*.md", are not treated as notes.`)
+}
+
+// addGlobalFlags registers the flags accepted both before the subcommand and
+// among its own flags.
+func addGlobalFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&jsonOutput, "json", jsonOutput, "print JSON instead of text (list, search, tags)")
+	fs.BoolVar(&autoCommit, "commit", autoCommit, "commit changes to the notes directory in git")
+	fs.BoolVar(&noEdit, "no-edit", noEdit, "create notes without opening the editor")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "print the changes link, delete, rename, merge, tag, clean, dedup, doctor and search --replace would make")
+	fs.BoolVar(&assumeYes, "yes", assumeYes, "answer yes to confirmations, as needed when stdin is not a terminal")
+	fs.BoolVar(&noColor, "no-color", noColor, "print search, list and tags output without color")
 }
 
+// newFlagSet returns a flag set for a subcommand that also accepts the
+// global flags.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	addGlobalFlags(fs)
+	return fs
+}
+
+// parseGlobalFlags consumes the global flags preceding the subcommand and
+// returns the remaining arguments.
+func parseGlobalFlags(args []string) ([]string, error) {
+	fs := flag.NewFlagSet("zettel", flag.ContinueOnError)
+	addGlobalFlags(fs)
+	// The notes directory is resolved before the subcommand parses its
+	// flags, so --vault is only accepted here.
+	fs.StringVar(&vaultName, "vault", "", "use the notes directory of vault `name`")
+	fs.StringVar(&dirOverride, "dir", "", "use the notes directory at `path`")
+	fs.StringVar(&dirOverride, "d", "", "shorthand for --dir")
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && commandAliases[args[0]] == "" {
+		if args[0] == "--" {
+			return args[1:], nil
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			return nil, usagef("Unknown flag: %v", args[0])
+		}
+
+		n := 1
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && b.IsBoolFlag()) {
+			n = 2
+		}
+		if err := parseFlagSet(fs, args[:min(n, len(args))]); err != nil {
+			return nil, err
+		}
+		args = args[min(n, len(args)):]
+	}
+
+	return args, nil
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error encoding JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// parseFlagSet parses args with fs. The flag package has already reported
+// a bad flag on stderr, so it becomes a usage error without a message.
+func parseFlagSet(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError{}
+	}
+	return err
+}
+
+// parseFlags parses fs against args, allowing flags to appear before, after
+// or between positional arguments, and returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := parseFlagSet(fs, args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, checkDryRun(fs.Name())
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// confirm asks a yes/no question on stdin and reports whether the answer
+// was yes. It is always yes under --yes, and no when stdin is not a
+// terminal, so that scripts fail instead of waiting for an answer.
+func confirm(prompt string) bool {
+	if assumeYes {
+		return true
+	}
+	if !isTerminal(os.Stdin) {
+		fmt.Println(prompt + " Not asking without a terminal; pass --yes to confirm")
+		return false
+	}
+	fmt.Print(prompt + " [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// dirOverride is set by the -d/--dir flag.
+var dirOverride string
+
 func getZettelHome() (string, error) {
-	if home := os.Getenv("ZETTEL_HOME"); home != "" {
-		return home, nil
+	dir, _, err := resolveZettelHome()
+	if err == nil && dirOverride != "" {
+		err = os.MkdirAll(dir, 0755)
+	}
+	return dir, err
+}
+
+// resolveZettelHome returns the notes directory along with where it was
+// set: --dir, --vault, ZETTEL_HOME, the config file, or the default.
+func resolveZettelHome() (dir, source string, err error) {
+	switch {
+	case dirOverride != "":
+		dir, err = expandHome(dirOverride)
+		return dir, "--dir flag", err
+	case vaultName != "":
+		dir, err = vaultDir(vaultName)
+		return dir, "--vault " + vaultName, err
+	case os.Getenv("ZETTEL_HOME") != "":
+		return os.Getenv("ZETTEL_HOME"), "ZETTEL_HOME", nil
 	}
 
-	home, err := os.UserHomeDir()
+	if cfg.Dir == defaultConfig().Dir {
+		dir, err = defaultZettelHome()
+		return dir, "default", err
+	}
+	if source, err = configPath(); err != nil {
+		return "", "", err
+	}
+	dir, err = expandHome(cfg.Dir)
+	return dir, source, err
+}
+
+// defaultZettelHome returns ~/zettelkasten, or on Linux with XDG_DATA_HOME
+// set, $XDG_DATA_HOME/zettelkasten unless notes already live in the former.
+func defaultZettelHome() (string, error) {
+	dir, err := expandHome(defaultConfig().Dir)
 	if err != nil {
 		return "", err
 	}
+	data := os.Getenv("XDG_DATA_HOME")
+	if runtime.GOOS != "linux" || !filepath.IsAbs(data) {
+		return dir, nil
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+	return filepath.Join(data, defaultHome), nil
+}
+
+// printWhere prints the notes directory and, on stderr, where it was set.
+func printWhere() error {
+	dir, source, err := resolveZettelHome()
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(struct {
+			Dir    string `json:"dir"`
+			Source string `json:"source"`
+		}{dir, source})
+	}
+	fmt.Println(dir)
+	fmt.Fprintln(os.Stderr, "from", source)
+	return nil
+}
 
-	return filepath.Join(home, defaultHome), nil
+// noteID turns a note argument into a bare ID, so that filenames printed by
+// other commands (e.g. "zettel edit $(zettel new)") are accepted too.
+func noteID(arg string) string {
+	if arg == "" {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(arg), noteExtension)
 }
 
 func generateID() string {
-	return time.Now().Format("20060102150405")
+	return time.Now().Format(cfg.IDFormat)
 }
 
-func createNewNote(zettelHome string) {
+// newNoteOptions controls how createNewNote names and fills a note.
+type newNoteOptions struct {
+	title       string
+	template    string
+	frontmatter bool
+	verbose     bool
+	// stdin reads the body of the note from standard input instead of
+	// opening the editor.
+	stdin bool
+	// clipboard starts the body of the note with the clipboard contents.
+	clipboard bool
+	// rawTitle names the note after the title with its spaces replaced by
+	// dashes instead of its titleSlug.
+	rawTitle bool
+	// linkFrom is a note to link to the new one, which links back to it
+	// when backlink is set.
+	linkFrom string
+	backlink bool
+}
+
+// initialNoteContent returns the body of a new note: the named template from
+// the templates directory or the configured template file if there is one,
+// otherwise a heading and the placeholder tag. With frontmatter set, the
+// title, creation time and tags go into a YAML frontmatter block instead.
+func initialNoteContent(zettelHome, id string, opts newNoteOptions) (string, error) {
+	path := ""
+	if opts.template != "" {
+		var err error
+		if path, err = templatePath(zettelHome, opts.template); err != nil {
+			return "", err
+		}
+	} else if cfg.Template != "" {
+		var err error
+		if path, err = expandHome(cfg.Template); err != nil {
+			return "", err
+		}
+	}
+
+	var content string
+	if path != "" {
+		var err error
+		if content, err = renderTemplate(path, id, opts.title); err != nil {
+			return "", err
+		}
+	} else if opts.frontmatter {
+		content = "# " + opts.title + "\n"
+	} else {
+		content = "# " + opts.title + "\n\n#" + placeholderTag() + "\n"
+	}
+
+	if opts.frontmatter {
+		created, _ := noteCreated(id)
+		content = "---\n" +
+			"title: " + opts.title + "\n" +
+			"created: " + created.Format(time.RFC3339) + "\n" +
+			"tags: [" + placeholderTag() + "]\n" +
+			"---\n" + content
+	}
+
+	return content, nil
+}
+
+// createNewNote creates a note named after the current time, followed by the
+// slug of the title if one is given, and opens it in the editor.
+func createNewNote(zettelHome string, opts newNoteOptions) error {
 	if err := os.MkdirAll(zettelHome, 0755); err != nil {
-		fmt.Println("Error creating directory:", err)
-		os.Exit(1)
+		return fmt.Errorf("Error creating directory: %w", err)
 	}
 
-	id := generateID()
-	notePath := filepath.Join(zettelHome, id+noteExtension)
+	if opts.linkFrom != "" {
+		if _, err := os.Stat(notePath(zettelHome, opts.linkFrom)); os.IsNotExist(err) {
+			return notFoundf("Note does not exist: %v", opts.linkFrom)
+		}
+	}
 
-	if err := os.WriteFile(notePath, []byte("# "+id+"\n"), 0644); err != nil {
-		fmt.Println("Error creating note:", err)
-		os.Exit(1)
+	var body []byte
+	if opts.stdin {
+		var err error
+		if body, err = io.ReadAll(os.Stdin); err != nil {
+			return fmt.Errorf("Error reading stdin: %w", err)
+		}
+	}
+	if opts.clipboard {
+		text, err := readClipboard()
+		if err != nil {
+			return fmt.Errorf("Error reading clipboard: %w", err)
+		}
+		body = []byte(text)
 	}
 
-	if err := openEditor(notePath); err != nil {
-		fmt.Println("Error opening editor:", err)
-		os.Exit(1)
+	id := newNoteID(opts.title, opts.rawTitle)
+	if opts.title == "" {
+		opts.title = id
+	}
+
+	id, f, err := createNoteFile(zettelHome, id)
+	if err != nil {
+		return fmt.Errorf("Error creating note: %w", err)
+	}
+	notePath := f.Name()
+
+	content, err := initialNoteContent(zettelHome, id, opts)
+	if err != nil {
+		f.Close()
+		os.Remove(notePath)
+		return fmt.Errorf("Error reading template: %w", err)
+	}
+	if len(body) > 0 {
+		content = strings.TrimRight(content, "\n") + "\n\n" + strings.TrimRight(string(body), "\n") + "\n"
+	}
+	if opts.backlink {
+		content += fmt.Sprintf("\n[[%s]]\n", opts.linkFrom)
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("Error creating note: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Error creating note: %w", err)
+	}
+
+	if !opts.stdin && !noEdit {
+		if err := openEditor(notePath); err != nil {
+			return fmt.Errorf("Error opening editor: %w", err)
+		}
 	}
 
-	fmt.Println("Created new note:", id)
+	linked := false
+	if opts.linkFrom != "" {
+		if linked, err = appendLinkOnce(zettelHome, filepath.Join(zettelHome, opts.linkFrom+noteExtension), id); err != nil {
+			return fmt.Errorf("Error writing link: %w", err)
+		}
+	}
+
+	recordChange(zettelHome, "new note "+id)
+
+	fmt.Println(id + noteExtension)
+	if opts.verbose {
+		fmt.Fprintln(os.Stderr, "Created new note:", id)
+	}
+	if linked && opts.backlink {
+		fmt.Fprintf(os.Stderr, "Linked %s <-> %s\n", opts.linkFrom, id)
+	} else if linked {
+		fmt.Fprintf(os.Stderr, "Linked %s -> %s\n", opts.linkFrom, id)
+	}
+	return nil
 }
 
-func editNote(zettelHome, id string) {
+func editNote(zettelHome, id string) error {
 	notePath := filepath.Join(zettelHome, id+noteExtension)
 	if _, err := os.Stat(notePath); os.IsNotExist(err) {
-		fmt.Println("Note does not exist:", id)
-		os.Exit(1)
+		return notFoundf("Note does not exist: %v", id)
 	}
 
 	if err := openEditor(notePath); err != nil {
-		fmt.Println("Error opening editor:", err)
-		os.Exit(1)
+		return fmt.Errorf("Error opening editor: %w", err)
 	}
+	updateSearchIndex(zettelHome)
+
+	if err := pushHistory(zettelHome, id); err != nil {
+		fmt.Println("Error writing history:", err)
+	}
+	return nil
+}
+
+// containsQuery reports whether content contains query, ignoring case if
+// ignoreCase is set.
+func containsQuery(content, query string, ignoreCase bool) bool {
+	if ignoreCase {
+		return strings.Contains(strings.ToLower(content), strings.ToLower(query))
+	}
+	return strings.Contains(content, query)
+}
+
+type searchResult struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Snippet  string `json:"snippet"`
 }
 
-func searchNotes(zettelHome, query string) {
-	err := filepath.Walk(zettelHome, func(path string, info os.FileInfo, err error) error {
+// searchOptions controls which notes searchNotes looks at and how it
+// prints the matches.
+type searchOptions struct {
+	ignoreCase      bool
+	includeArchived bool
+	// filesOnly prints only the IDs of matching notes.
+	filesOnly bool
+	// context is the number of lines printed around each matching line.
+	context int
+	// excludeTags hides the notes carrying any of these tags.
+	excludeTags []string
+	// titleOnly matches the query against titleOrSlug instead of the
+	// content.
+	titleOnly bool
+	// count prints only the number of matching notes, or of matching lines
+	// with countLines.
+	count      bool
+	countLines bool
+	// failEmpty exits with exitNoMatch when nothing matches.
+	failEmpty bool
+	// includeMeta also matches the query in the frontmatter.
+	includeMeta bool
+	// since and until, when set, limit the search to the notes created in
+	// [since, until) according to their IDs.
+	since, until time.Time
+}
+
+func searchNotes(zettelHome, query string, opts searchOptions) error {
+	results := []searchResult{}
+	printed := false
+	suppressed, matched, matchedLines, undated := 0, 0, 0, 0
+	bounded := !opts.since.IsZero() || !opts.until.IsZero()
+	visit := func(path string) error {
+		filename, err := filepath.Rel(zettelHome, path)
 		if err != nil {
 			return err
 		}
-
-		if !info.IsDir() && filepath.Ext(path) == noteExtension {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
+		id := filename[:len(filename)-len(noteExtension)]
+		if bounded {
+			created, ok := noteCreated(id)
+			if !ok {
+				undated++
+				return nil
+			}
+			if created.Before(opts.since) || !opts.until.IsZero() && !created.Before(opts.until) {
+				return nil
 			}
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		// Frontmatter is left out unless includeMeta is set, counting its
+		// lines so that matches keep their line numbers in the file.
+		text, firstLine := string(content), 1
+		if !opts.includeMeta {
+			_, body, _ := splitFrontmatter(text)
+			firstLine += strings.Count(text[:len(text)-len(body)], "\n")
+			text = body
+		}
 
-			if strings.Contains(string(content), query) {
-				fmt.Println("Found in:", filepath.Base(path[:len(path)-len(noteExtension)]))
+		title := ""
+		if opts.titleOnly {
+			if title = titleOrSlug(string(content), id); !containsQuery(title, query, opts.ignoreCase) {
+				return nil
 			}
+		} else if !containsQuery(text, query, opts.ignoreCase) {
+			return nil
+		}
+		if len(opts.excludeTags) > 0 && hasTags(noteTags(string(content)), opts.excludeTags, true) {
+			suppressed++
+			return nil
+		}
+		matched++
+		switch {
+		case opts.countLines && opts.titleOnly:
+			matchedLines++
+		case opts.countLines:
+			matchedLines += countMatchingLines(text, query, opts.ignoreCase)
+		case opts.count:
+		case jsonOutput && opts.titleOnly:
+			results = append(results, searchResult{id, filename, title})
+		case jsonOutput:
+			results = append(results, searchResult{id, filename, matchExcerpt(text, query, opts.ignoreCase)})
+		case opts.filesOnly:
+			fmt.Println("Found in:", colorize(colorFile, id))
+		case opts.titleOnly:
+			fmt.Printf("%s: %s\n", colorize(colorFile, id), highlightQuery(title, query, opts.ignoreCase))
+		default:
+			if printed {
+				fmt.Println()
+			}
+			fmt.Println(colorize(colorFile, id))
+			printMatchingLines(text, firstLine, query, opts.ignoreCase, opts.context)
+			printed = true
 		}
 		return nil
-	})
+	}
+
+	var err error
+	// The index does not cover the slugs titleOnly also matches.
+	if ids, ok := indexedCandidates(zettelHome, query); ok && !opts.includeArchived && !opts.titleOnly {
+		for _, id := range ids {
+			if err = visit(notePath(zettelHome, id)); err != nil {
+				break
+			}
+		}
+	} else {
+		err = filepath.Walk(zettelHome, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && path != zettelHome && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if info.IsDir() && !opts.includeArchived && path == filepath.Join(zettelHome, archiveDir) {
+				return filepath.SkipDir
+			}
+			if isIgnored(zettelHome, path, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() && filepath.Ext(path) == noteExtension {
+				return visit(path)
+			}
+			return nil
+		})
+	}
 
 	if err != nil {
 		fmt.Println("Search error:", err)
 	}
+	if suppressed > 0 {
+		fmt.Fprintf(os.Stderr, "%d matching notes hidden by --exclude-tag\n", suppressed)
+	}
+	if undated > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d notes without a timestamp in their ID\n", undated)
+	}
+
+	switch {
+	case opts.countLines:
+		fmt.Println(matchedLines)
+	case opts.count:
+		fmt.Println(matched)
+	case jsonOutput:
+		return printJSON(results)
+	}
+	if opts.failEmpty && matched == 0 {
+		return noMatchError{}
+	}
+	return nil
+}
+
+// countMatchingLines returns how many lines of content contain query.
+func countMatchingLines(content, query string, ignoreCase bool) int {
+	n := 0
+	for _, line := range strings.Split(content, "\n") {
+		if containsQuery(line, query, ignoreCase) {
+			n++
+		}
+	}
+	return n
 }
 
-func linkNotes(zettelHome, src, dest string) {
+// printMatchingLines prints the lines of content containing query in the
+// style of grep: "N:line" for matches, "N-line" for the context lines around
+// them and "--" between groups that are not adjacent. Lines are numbered
+// from firstLine.
+func printMatchingLines(content string, firstLine int, query string, ignoreCase bool, context int) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	last := -1
+	for i, line := range lines {
+		if !containsQuery(line, query, ignoreCase) {
+			continue
+		}
+
+		start := max(i-context, last+1)
+		if last >= 0 && start > last+1 {
+			fmt.Println("--")
+		}
+		for j := start; j < i; j++ {
+			fmt.Printf("%d-%s\n", firstLine+j, lines[j])
+		}
+		fmt.Printf("%d:%s\n", firstLine+i, highlightQuery(line, query, ignoreCase))
+		last = i
+
+		// Trailing context stops at the next match, which prints its
+		// own leading context.
+		for j := i + 1; j <= min(i+context, len(lines)-1); j++ {
+			if containsQuery(lines[j], query, ignoreCase) {
+				break
+			}
+			fmt.Printf("%d-%s\n", firstLine+j, lines[j])
+			last = j
+		}
+	}
+}
+
+func linkNotes(zettelHome, src, dest string, bidirectional bool) error {
 	srcPath := filepath.Join(zettelHome, src+noteExtension)
 	destPath := filepath.Join(zettelHome, dest+noteExtension)
 
 	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
-		fmt.Println("Source note does not exist:", src)
-		os.Exit(1)
+		return notFoundf("Source note does not exist: %v", src)
 	}
 
 	if _, err := os.Stat(destPath); os.IsNotExist(err) {
-		fmt.Println("Destination note does not exist:", dest)
-		os.Exit(1)
+		return notFoundf("Destination note does not exist: %v", dest)
+	}
+
+	links := [][3]string{{srcPath, src, dest}}
+	if bidirectional {
+		links = append(links, [3]string{destPath, dest, src})
 	}
 
-	f, err := os.OpenFile(srcPath, os.O_APPEND|os.O_WRONLY, 0644)
+	for _, l := range links {
+		added, err := appendLinkOnce(zettelHome, l[0], l[2])
+		if err != nil {
+			return fmt.Errorf("Error writing link: %w", err)
+		}
+		if dryRun {
+			continue
+		}
+		if added {
+			fmt.Printf("Linked %s -> %s\n", l[1], l[2])
+		} else {
+			fmt.Printf("Already linked %s -> %s\n", l[1], l[2])
+		}
+	}
+
+	if !dryRun {
+		recordChange(zettelHome, "link "+src+" -> "+dest)
+	}
+	return nil
+}
+
+// appendLinkOnce appends a [[dest]] link to the note at srcPath unless it
+// already links to dest, by ID, slug, alias or title, and reports whether
+// it did.
+func appendLinkOnce(zettelHome, srcPath, dest string) (bool, error) {
+	content, err := os.ReadFile(srcPath)
+	if err != nil {
+		return false, err
+	}
+	r, err := newLinkResolver(zettelHome)
 	if err != nil {
-		fmt.Println("Error opening note:", err)
-		os.Exit(1)
+		return false, err
 	}
-	defer f.Close()
+	if r.linksTo(string(content), dest) {
+		return false, nil
+	}
+	return true, appendLink(zettelHome, srcPath, dest)
+}
 
-	if _, err = f.WriteString(fmt.Sprintf("\n[[%s]]\n", dest)); err != nil {
-		fmt.Println("Error writing link:", err)
-		os.Exit(1)
+// appendLink appends a [[dest]] link to the note at srcPath.
+func appendLink(zettelHome, srcPath, dest string) error {
+	content, err := os.ReadFile(srcPath)
+	if err != nil {
+		return err
 	}
+	content = fmt.Appendf(content, "\n[[%s]]\n", dest)
+	return stageWrite(zettelHome, srcPath, content, "link "+dest)
+}
+
+// defaultEditor is used when neither EDITOR nor the config names one.
+const defaultEditor = "nano"
 
-	fmt.Printf("Linked %s -> %s\n", src, dest)
+// multiFileEditors are the editors known to open several files given at
+// once, as buffers or tabs.
+var multiFileEditors = []string{
+	"vi", "vim", "nvim", "gvim", "emacs", "emacsclient", "nano", "micro", "hx", "kak",
+	"code", "codium", "subl", "gedit", "kate", "mate",
 }
 
-func openEditor(path string) error {
+// openEditor opens paths in the editor, all at once if it is known to
+// support that and one after the other otherwise.
+func openEditor(paths ...string) error {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
-		return fmt.Errorf("EDITOR environment variable not set")
+		editor = cfg.Editor
+	}
+	if editor == "" {
+		editor = defaultEditor
 	}
 
-	cmd := exec.Command(editor, path)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	args, err := splitCommand(editor)
+	if err != nil {
+		return fmt.Errorf("parsing editor %q: %w", editor, err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("editor %q is empty", editor)
+	}
 
-	return cmd.Run()
+	batches := [][]string{paths}
+	if len(paths) > 1 && !slices.Contains(multiFileEditors, strings.TrimSuffix(filepath.Base(args[0]), ".exe")) {
+		batches = batches[:0]
+		for _, path := range paths {
+			batches = append(batches, []string{path})
+		}
+	}
+
+	for _, batch := range batches {
+		cmd := exec.Command(args[0], append(args[1:], batch...)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitCommand splits a command line such as `code --wait` into its words
+// the way a shell would for simple cases: words are separated by spaces,
+// single quotes keep their contents literal, and double quotes group words
+// while still honouring backslash escapes.
+func splitCommand(s string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
 }
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so that
+// runZettel can check the output and exit status of whole commands.
+const runMainEnv = "ZETTEL_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) != "" {
+		os.Args = append([]string{"zettel"}, os.Args[1:]...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testVault writes notes, keyed by ID, into a new notes directory and
+// returns its path.
+func testVault(t *testing.T, notes map[string]string) string {
+	t.Helper()
+	home := t.TempDir()
+	for id, content := range notes {
+		path := notePath(home, id)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+// readTestNote returns the content of the note id in home.
+func readTestNote(t *testing.T, home, id string) string {
+	t.Helper()
+	content, err := readNote(home, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// runZettel runs zettel with args on the notes in home, with an empty
+// config and stdin that is not a terminal, and returns its stdout and exit
+// status.
+func runZettel(t *testing.T, home string, args ...string) (string, int) {
+	t.Helper()
+	return runZettelConfig(t, home, "", args...)
+}
+
+// runZettelConfig is runZettel with config as the config file.
+func runZettelConfig(t *testing.T, home, config string, args ...string) (string, int) {
+	t.Helper()
+	configHome := t.TempDir()
+	if config != "" {
+		if err := os.Mkdir(filepath.Join(configHome, "zettel"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(configHome, "zettel", configFileName), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		runMainEnv+"=1",
+		"ZETTEL_HOME="+home,
+		"HOME="+t.TempDir(),
+		"XDG_CONFIG_HOME="+configHome,
+		"EDITOR=true",
+		"NO_COLOR=1",
+	)
+	cmd.Stdin = strings.NewReader("")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String(), 0
+}
+
+func TestNewPrintsOnlyFilename(t *testing.T) {
+	home := testVault(t, nil)
+	for _, args := range [][]string{
+		{"new", "--no-edit", "Hello world"},
+		{"new", "--no-edit", "--verbose", "Hello again"},
+	} {
+		out, code := runZettel(t, home, args...)
+		if code != 0 {
+			t.Fatalf("%v exited %d", args, code)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		slug := strings.ToLower(strings.ReplaceAll(args[len(args)-1], " ", "-"))
+		if len(lines) != 1 || !strings.HasSuffix(lines[0], "-"+slug+noteExtension) {
+			t.Errorf("%v printed %q, want only the filename", args, out)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(home, lines[0])); err != nil {
+			t.Errorf("%v: printed filename does not exist: %v", args, err)
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{"vim", []string{"vim"}, false},
+		{"code --wait", []string{"code", "--wait"}, false},
+		{"  emacsclient  -nw\t", []string{"emacsclient", "-nw"}, false},
+		{`"/Applications/My Editor/bin/edit" -w`, []string{"/Applications/My Editor/bin/edit", "-w"}, false},
+		{`subl -n '--command=a b'`, []string{"subl", "-n", "--command=a b"}, false},
+		{`my\ editor "say \"hi\"" '\n'`, []string{"my editor", `say "hi"`, `\n`}, false},
+		{`vim ""`, []string{"vim", ""}, false},
+		{"", nil, false},
+		{`vim "unterminated`, nil, true},
+		{`vim \`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, %v; want %q", tt.command, got, err, tt.want)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	home := testVault(t, map[string]string{"20240101120000-idea": "# Idea\n\nSome text\n"})
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"show", "20240101120000-idea"}, 0},
+		{[]string{"search", "--fail-empty", "text"}, 0},
+		{[]string{"no-such-command"}, exitUsage},
+		{[]string{"show"}, exitUsage},
+		{[]string{"search", "--no-such-flag", "x"}, exitUsage},
+		{[]string{"show", "missing"}, exitNotFound},
+		{[]string{"open-id", "missing"}, exitNotFound},
+		{[]string{"open-id", "20240101"}, 0},
+		{[]string{"search", "nothing"}, 0},
+		{[]string{"search", "--fail-empty", "nothing"}, exitNoMatch},
+	}
+	for _, tt := range tests {
+		if out, code := runZettel(t, home, tt.args...); code != tt.code {
+			t.Errorf("%v exited %d, want %d; output %q", tt.args, code, tt.code, out)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// mergeSeparator sets the merged body of a note apart from the target's own.
+const mergeSeparator = "\n\n---\n\n"
+
+// mergedContent appends the body of source to target. The target keeps its
+// frontmatter and heading; the source's heading becomes a "## " one, and
+// the source's tags are added to the target's without repeating any. Links
+// r resolves to either note are dropped, as they would link the merged note
+// to itself.
+func mergedContent(target, source string, r *linkResolver, sourceID, targetID string) string {
+	for _, id := range []string{sourceID, targetID} {
+		target, _ = removeLinks(target, r, id)
+		source, _ = removeLinks(source, r, id)
+	}
+
+	_, body, _ := splitFrontmatter(source)
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# ") {
+			lines[i] = "#" + line
+			break
+		}
+	}
+	body = strings.Join(lines, "\n")
+
+	have := noteTags(target)
+	for _, tag := range have {
+		body, _ = removeTag(body, tag)
+	}
+
+	present := append(have, noteTags(body)...)
+	var missing []string
+	for _, tag := range frontmatterList(source, "tags") {
+		if tag = strings.TrimPrefix(tag, "#"); !hasTags(present, []string{tag}, false) {
+			missing = append(missing, tag)
+			present = append(present, tag)
+		}
+	}
+	if len(missing) > 0 {
+		if listed := frontmatterList(target, "tags"); len(listed) > 0 {
+			target = setFrontmatterList(target, "tags", append(listed, missing...))
+		} else {
+			body = strings.TrimRight(body, "\n") + "\n\n#" + strings.Join(missing, " #")
+		}
+	}
+
+	return strings.TrimRight(target, "\n") + mergeSeparator + strings.Trim(body, "\n") + "\n"
+}
+
+// mergeNotes appends source to target, points the links to source at
+// target and deletes source unless keepSource is set.
+func mergeNotes(zettelHome, sourceID, targetID string, keepSource bool) error {
+	if sourceID == targetID {
+		return usagef("Cannot merge a note into itself: %v", sourceID)
+	}
+	for _, id := range []string{sourceID, targetID} {
+		if _, err := os.Stat(notePath(zettelHome, id)); os.IsNotExist(err) {
+			return notFoundf("Note does not exist: %v", id)
+		}
+	}
+
+	prompt := fmt.Sprintf("Merge %s into %s and delete %s?", sourceID, targetID, sourceID)
+	if keepSource {
+		prompt = fmt.Sprintf("Merge %s into %s?", sourceID, targetID)
+	}
+	if !dryRun && !confirm(prompt) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	// The links between the two notes are resolved as they were before
+	// relinking, which rewrites them to point at the target.
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+	links, notes, err := relinkNotes(zettelHome, sourceID, targetID)
+	if err != nil {
+		return fmt.Errorf("Error updating links: %w", err)
+	}
+
+	source, err := readNote(zettelHome, sourceID)
+	if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+	target, err := readNote(zettelHome, targetID)
+	if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+	if err := stageWrite(zettelHome, notePath(zettelHome, targetID), []byte(mergedContent(target, source, r, sourceID, targetID)), "merge "+sourceID); err != nil {
+		return fmt.Errorf("Error writing note: %w", err)
+	}
+	if !keepSource {
+		if err := stageRemove(zettelHome, notePath(zettelHome, sourceID)); err != nil {
+			return fmt.Errorf("Error deleting note: %w", err)
+		}
+		if err := retargetAliases(zettelHome, sourceID, targetID); err != nil {
+			return fmt.Errorf("Error updating aliases: %w", err)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("Would merge %s into %s, updating %d links in %d notes\n", sourceID, targetID, links, notes)
+		return nil
+	}
+	recordChange(zettelHome, "merge "+sourceID+" -> "+targetID)
+	fmt.Printf("Merged %s into %s, updated %d links in %d notes\n", sourceID, targetID, links, notes)
+	return nil
+}
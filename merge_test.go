@@ -0,0 +1,102 @@
+package main
+
+import "testing"
+
+func TestMergedContent(t *testing.T) {
+	const sourceID, targetID = "20240101120000-source", "20240101130000-target"
+	r, err := newLinkResolver(testVault(t, map[string]string{
+		sourceID:     "# Source\n",
+		targetID:     "# Target\n",
+		"other-note": "# Other\n",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, target, source, want string
+	}{
+		{
+			"heading demoted",
+			"# Target\n\nOwn text\n",
+			"# Source\n\nMore text\n",
+			"# Target\n\nOwn text\n\n---\n\n## Source\n\nMore text\n",
+		},
+		{
+			"only the first heading",
+			"# Target\n",
+			"intro\n# Source\n# Second\n",
+			"# Target\n\n---\n\nintro\n## Source\n# Second\n",
+		},
+		{
+			"shared tags dropped",
+			"# Target\n\n#shared #own\n",
+			"# Source\n\nText #shared here\n\n#shared #new\n",
+			"# Target\n\n#shared #own\n\n---\n\n## Source\n\nText here\n\n#new\n",
+		},
+		{
+			"frontmatter tags into target list",
+			"---\ntags: [own]\n---\n# Target\n",
+			"---\ntags: [own, new]\n---\n# Source\n",
+			"---\ntags: [own, new]\n---\n# Target\n\n---\n\n## Source\n",
+		},
+		{
+			"frontmatter tags into body",
+			"# Target\n",
+			"---\ntags: [new, other]\n---\n# Source\n\nText\n",
+			"# Target\n\n---\n\n## Source\n\nText\n\n#new #other\n",
+		},
+		{
+			"nested parent tag kept",
+			"# Target\n\n#project/zettel\n",
+			"---\ntags: [project]\n---\nText\n",
+			"# Target\n\n#project/zettel\n\n---\n\nText\n",
+		},
+		{
+			"links between the notes dropped",
+			"# Target\n\nsee [[20240101130000-target]] and [[other-note]]\n\n[[source#Part]]\n",
+			"# Source\n\nBack to [[Target]].\n\n[[target]] [[20240101120000-source.md]]\n",
+			"# Target\n\nsee  and [[other-note]]\n\n---\n\n## Source\n\nBack to .\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergedContent(tt.target, tt.source, r, sourceID, targetID); got != tt.want {
+				t.Errorf("mergedContent(%q, %q) =\n%q\nwant\n%q", tt.target, tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"src":    "# Src\n\nsee [[dst]]\n",
+		"dst":    "# Dst\n\nsee [[src#Part]]\n",
+		"other":  "[[src|the source]]\n",
+		"target": "# Target\n",
+	})
+
+	if _, code := runZettel(t, home, "merge", "src", "src"); code != exitUsage {
+		t.Errorf("merging a note into itself exited %d, want %d", code, exitUsage)
+	}
+	if _, code := runZettel(t, home, "merge", "missing", "dst"); code != exitNotFound {
+		t.Errorf("merging a missing note exited %d, want %d", code, exitNotFound)
+	}
+	runZettel(t, home, "merge", "src", "dst")
+	if _, code := runZettel(t, home, "show", "src"); code != 0 {
+		t.Fatal("merge deleted the source without confirmation")
+	}
+
+	if _, code := runZettel(t, home, "--yes", "merge", "src", "dst"); code != 0 {
+		t.Fatalf("merge exited %d", code)
+	}
+	if got, want := readTestNote(t, home, "dst"), "# Dst\n\nsee\n\n---\n\n## Src\n\nsee\n"; got != want {
+		t.Errorf("dst = %q, want %q", got, want)
+	}
+	if got, want := readTestNote(t, home, "other"), "[[dst|the source]]\n"; got != want {
+		t.Errorf("other = %q, want %q", got, want)
+	}
+	if _, code := runZettel(t, home, "show", "src"); code != exitNotFound {
+		t.Error("merge kept the source")
+	}
+}
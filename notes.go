@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// listNoteIDs returns the IDs of all notes in zettelHome that the ignore
+// file does not exclude, sorted.
+func listNoteIDs(zettelHome string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(zettelHome, "*"+noteExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !isIgnored(zettelHome, path, false) {
+			ids = append(ids, noteID(path))
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+func notePath(zettelHome, id string) string {
+	return filepath.Join(zettelHome, id+noteExtension)
+}
+
+func readNote(zettelHome, id string) (string, error) {
+	content, err := os.ReadFile(notePath(zettelHome, id))
+	return string(content), err
+}
+
+// splitFrontmatter separates a leading "---" delimited block from content.
+// ok is false, and body is content unchanged, when there is none.
+func splitFrontmatter(content string) (block, body string, ok bool) {
+	if !strings.HasPrefix(content, "---\n") {
+		return "", content, false
+	}
+
+	rest := content[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return "", content, false
+	}
+
+	body = rest[end+len("\n---"):]
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = ""
+	}
+
+	return rest[:end], body, true
+}
+
+// parseFrontmatter splits a leading "---" delimited block of "key: value"
+// lines from content. It returns the fields and the remaining body; content
+// without frontmatter yields no fields and the content unchanged.
+func parseFrontmatter(content string) (map[string]string, string) {
+	fields := map[string]string{}
+	block, body, _ := splitFrontmatter(content)
+
+	for _, line := range strings.Split(block, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return fields, body
+}
+
+// frontmatterList reads a list field from the frontmatter of content, written
+// either inline ("tags: [a, b]" or "tags: a, b") or as a block of "- a"
+// items.
+func frontmatterList(content, key string) []string {
+	block, _, ok := splitFrontmatter(content)
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	inList := false
+	for _, line := range strings.Split(block, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inList {
+			if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+				values = append(values, strings.Trim(strings.TrimSpace(item), `"'`))
+				continue
+			}
+			inList = false
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) != key {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			inList = true
+			continue
+		}
+		for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+			if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+
+	return values
+}
+
+// setFrontmatterList rewrites a list field in the frontmatter of content as
+// an inline list, replacing any block items. Content without the field is
+// returned unchanged.
+func setFrontmatterList(content, key string, values []string) string {
+	block, body, ok := splitFrontmatter(content)
+	if !ok {
+		return content
+	}
+
+	var lines []string
+	inList := false
+	for _, line := range strings.Split(block, "\n") {
+		if inList {
+			if strings.HasPrefix(strings.TrimSpace(line), "- ") {
+				continue
+			}
+			inList = false
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) == key {
+			inList = strings.TrimSpace(value) == ""
+			line = key + ": [" + strings.Join(values, ", ") + "]"
+		}
+		lines = append(lines, line)
+	}
+
+	return "---\n" + strings.Join(lines, "\n") + "\n---\n" + body
+}
+
+// noteTitle returns the text of the first "# " heading in content, or id
+// when there is none.
+func noteTitle(content, id string) string {
+	_, body := parseFrontmatter(content)
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+	}
+	return id
+}
+
+// titleOrSlug returns the name a note goes by: its first "# " heading, or
+// else the slug of its ID with dashes read as spaces. Notes with a bare
+// timestamp ID and no heading have no name.
+func titleOrSlug(content, id string) string {
+	if title := noteTitle(content, id); title != id {
+		return strings.TrimSpace(title)
+	}
+	base := filepath.Base(id)
+	prefix, _, _ := idTimestamp(base)
+	slug := strings.TrimPrefix(base[len(prefix):], "-")
+	return strings.TrimSpace(strings.ReplaceAll(slug, "-", " "))
+}
+
+// defaultIDFormat makes new note IDs precise to the minute; createNoteFile
+// tells apart notes created within the same minute.
+const defaultIDFormat = "200601021504"
+
+// idLayouts are the timestamp layouts note IDs start with, besides the
+// configured id_format.
+var idLayouts = []string{"20060102150405", "200601021504"}
+
+// idTimestamp splits the timestamp prefix from id, returning the prefix and
+// the time it encodes. Longer layouts are tried first, so that a minute
+// layout does not take the first digits of a timestamp with seconds.
+func idTimestamp(id string) (string, time.Time, bool) {
+	layouts := append([]string{cfg.IDFormat}, idLayouts...)
+	sort.SliceStable(layouts, func(i, j int) bool { return len(layouts[i]) > len(layouts[j]) })
+	for _, layout := range layouts {
+		if len(id) < len(layout) {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, id[:len(layout)], time.Local); err == nil {
+			return id[:len(layout)], t, true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// noteCreated parses the creation time from the timestamp prefix of id,
+// ignoring a directory such as that of archived notes.
+func noteCreated(id string) (time.Time, bool) {
+	_, t, ok := idTimestamp(filepath.Base(id))
+	return t, ok
+}
+
+// availableNoteID returns the ID createNoteFile would use for id if it
+// were called now.
+func availableNoteID(zettelHome, id string) string {
+	candidate := id
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(notePath(zettelHome, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", id, n)
+	}
+}
+
+// slugify turns a title into a slug by replacing its spaces with dashes,
+// the form links and aliases are matched in. New notes are named with
+// titleSlug unless asked to keep the raw title.
+func slugify(title string) string {
+	return strings.ReplaceAll(strings.TrimSpace(title), " ", "-")
+}
+
+// newNoteID returns the ID of a note created now with title: the timestamp
+// followed by the titleSlug of title, or with raw by the title with its
+// spaces replaced by dashes.
+func newNoteID(title string, raw bool) string {
+	slug := titleSlug(title)
+	if raw {
+		slug = slugify(title)
+	}
+	if slug == "" {
+		return generateID()
+	}
+	return generateID() + "-" + slug
+}
+
+// transliterations spell letters with accents and ligatures in ASCII.
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ŕ': "r", 'ř': "r", 'ß': "ss", 'ś': "s", 'š': "s", 'ş': "s", 'ș': "s",
+	'ť': "t", 'ţ': "t", 'ț': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// titleSlug turns a title into the slug part of a note filename: lowercase,
+// with accented letters transliterated as in é to e, and every run of other
+// characters than letters and digits, such as spaces, "/", ":" or emoji,
+// made a single dash. It is empty when the title has no letters or digits.
+func titleSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		s, ok := transliterations[r]
+		if !ok && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		if ok {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// createNoteFile exclusively creates the file of a new note with ID id. When
+// a note with that ID already exists, as for two notes created within the
+// same second, it appends "-2", "-3" and so on, so that no note is ever
+// overwritten. It returns the ID used and the open file.
+func createNoteFile(zettelHome, id string) (string, *os.File, error) {
+	candidate := id
+	for n := 2; ; n++ {
+		f, err := os.OpenFile(notePath(zettelHome, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return candidate, f, nil
+		}
+		if !os.IsExist(err) {
+			return "", nil, err
+		}
+		candidate = fmt.Sprintf("%s-%d", id, n)
+	}
+}
+
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so an interrupted write never leaves a truncated note.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
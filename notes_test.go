@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCreateNoteFile(t *testing.T) {
+	home := testVault(t, map[string]string{"20240101120000-idea": "# Existing\n"})
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		id, f, err := createNoteFile(home, "20240101120000-idea")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		got = append(got, id)
+	}
+	want := []string{"20240101120000-idea-2", "20240101120000-idea-3", "20240101120000-idea-4"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("note %d created as %q, want %q", i, got[i], want[i])
+		}
+	}
+	if content := readTestNote(t, home, "20240101120000-idea"); content != "# Existing\n" {
+		t.Errorf("existing note overwritten: %q", content)
+	}
+}
+
+func TestNewTwiceInOneMinute(t *testing.T) {
+	home := testVault(t, nil)
+	names := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		out, code := runZettel(t, home, "new", "--no-edit", "Same title")
+		if code != 0 {
+			t.Fatalf("new exited %d", code)
+		}
+		if !regexp.MustCompile(`^\d{12}-same-title(-2)?` + regexp.QuoteMeta(noteExtension) + "\n$").MatchString(out) {
+			t.Errorf("new printed %q, want an ID with the default format", out)
+		}
+		names[out] = true
+	}
+	entries, err := os.ReadDir(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || len(entries) != 2 {
+		t.Errorf("two notes created back to back printed %v and left %d files", sortedKeys(names), len(entries))
+	}
+}
+
+func TestNoteExtension(t *testing.T) {
+	defer func(ext string) { noteExtension = ext }(noteExtension)
+	noteExtension = ".txt"
+	home := testVault(t, map[string]string{
+		"20240101120000-alpha": "# Alpha\n\nSee [[beta.txt]] and [[Beta]].\n",
+		"20240102120000-beta":  "# Beta\n\nfindme\n",
+	})
+	if err := os.WriteFile(filepath.Join(home, "stray.md"), []byte("# Stray\n\nfindme [[beta]]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := listNoteIDs(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"20240101120000-alpha", "20240102120000-beta"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("listNoteIDs = %q, want %q", ids, want)
+	}
+	if got := noteID("notes/20240101120000-alpha.txt"); got != "20240101120000-alpha" {
+		t.Errorf("noteID = %q", got)
+	}
+	if got := parseLinks("[[beta.txt]] [[beta.md]]"); !reflect.DeepEqual(got, []string{"beta", "beta.md"}) {
+		t.Errorf("parseLinks = %q", got)
+	}
+
+	const config = `note_extension = "txt"` + "\n"
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"list"}, "20240101120000-alpha.txt"},
+		{[]string{"search", "--files-only", "findme"}, "Found in: 20240102120000-beta\n"},
+		{[]string{"backlinks", "20240102120000-beta.txt"}, "20240101120000-alpha\n"},
+	}
+	for _, tt := range tests {
+		out, code := runZettelConfig(t, home, config, tt.args...)
+		if code != 0 || !strings.Contains(out, tt.want) || strings.Contains(out, "stray") {
+			t.Errorf("%v exited %d printing %q, want %q", tt.args, code, out, tt.want)
+		}
+	}
+}
+
+func TestTitleSlug(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Trim   me  ", "trim-me"},
+		{"Café crème brûlée", "cafe-creme-brulee"},
+		{"Straße Œuvre Łódź", "strasse-oeuvre-lodz"},
+		{"Ideas: part 2/3", "ideas-part-2-3"},
+		{"C:\\path\\to", "c-path-to"},
+		{"Launch 🚀 day 🎉", "launch-day"},
+		{"日本語のノート", "日本語のノート"},
+		{"Привет мир", "привет-мир"},
+		{"what?!...", "what"},
+		{"🚀 / :", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := titleSlug(tt.title); got != tt.want {
+			t.Errorf("titleSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestIDTimestamp(t *testing.T) {
+	defer func(c config) { cfg = c }(cfg)
+	tests := []struct {
+		format, id, prefix string
+	}{
+		{defaultIDFormat, "202403150930-idea", "202403150930"},
+		{defaultIDFormat, "20240315093012-idea", "20240315093012"},
+		{defaultIDFormat, "202403150930-12-rules", "202403150930"},
+		{defaultIDFormat, "202403150930", "202403150930"},
+		{"2006-01-02T1504", "2024-03-15T0930-idea", "2024-03-15T0930"},
+		{defaultIDFormat, "idea", ""},
+	}
+	for _, tt := range tests {
+		cfg.IDFormat = tt.format
+		if prefix, _, _ := idTimestamp(tt.id); prefix != tt.prefix {
+			t.Errorf("idTimestamp(%q) with id_format %q = %q, want %q", tt.id, tt.format, prefix, tt.prefix)
+		}
+	}
+}
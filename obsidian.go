@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// obsidianUnsafe are the characters Obsidian does not allow in filenames,
+// which rule out naming a note after its title.
+const obsidianUnsafe = `*"\/<>:|?#^[]`
+
+// obsidianNames returns the filename, without extension, each note gets in
+// the exported vault: its title when titleLinks is set and the title is
+// usable and unique, and its ID otherwise.
+func obsidianNames(r *linkResolver, titleLinks bool) map[string]string {
+	names := make(map[string]string, len(r.ids))
+	for id := range r.ids {
+		names[id] = id
+	}
+	if !titleLinks {
+		return names
+	}
+
+	byTitle := map[string][]string{}
+	for id := range r.ids {
+		title := noteTitle(r.contents[id], id)
+		if title != id && title != "" && !strings.ContainsAny(title, obsidianUnsafe) && !strings.HasPrefix(title, ".") {
+			key := strings.ToLower(title)
+			byTitle[key] = append(byTitle[key], id)
+		}
+	}
+	for _, ids := range byTitle {
+		if len(ids) == 1 {
+			names[ids[0]] = noteTitle(r.contents[ids[0]], ids[0])
+		}
+	}
+	return names
+}
+
+// obsidianContent converts a note for Obsidian: links that zettel resolves
+// by slug, title or alias point at the exported filename, keeping their
+// text, and the frontmatter tags are listed without '#'.
+func obsidianContent(r *linkResolver, names map[string]string, content string) string {
+	content = wikiLinkRegex.ReplaceAllStringFunc(content, func(link string) string {
+		inner := link[2 : len(link)-2]
+		target := inner
+		if i := strings.IndexAny(inner, "#|"); i >= 0 {
+			target = inner[:i]
+		}
+		id, _ := r.resolve(strings.TrimSuffix(target, noteExtension))
+		if _, ok := names[id]; !ok || names[id] == target {
+			return link
+		}
+		rest := inner[len(target):]
+		if !strings.Contains(rest, "|") && names[id] != noteTitle(r.contents[id], id) {
+			rest += "|" + target
+		}
+		return "[[" + names[id] + rest + "]]"
+	})
+
+	if listed := frontmatterList(content, "tags"); len(listed) > 0 {
+		for i, tag := range listed {
+			listed[i] = strings.TrimPrefix(tag, "#")
+		}
+		content = setFrontmatterList(content, "tags", listed)
+	}
+	return content
+}
+
+// exportObsidian copies the notes into outDir as an Obsidian vault, leaving
+// the notes directory untouched. With titleLinks the notes are named after
+// their titles and links point at those names, as Obsidian prefers; notes
+// whose titles are shared or not valid filenames keep their IDs.
+func exportObsidian(zettelHome, outDir string, titleLinks bool) error {
+	absOut, err := filepath.Abs(outDir)
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+	absHome, err := filepath.Abs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+	if rel, err := filepath.Rel(absHome, absOut); err == nil && !strings.HasPrefix(rel, "..") {
+		return usagef("Cannot export into the notes directory: %v", outDir)
+	}
+
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+	names := obsidianNames(r, titleLinks)
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("Error creating directory: %w", err)
+	}
+	for _, id := range sortedKeys(r.contents) {
+		path := filepath.Join(outDir, names[id]+noteExtension)
+		if err := os.WriteFile(path, []byte(obsidianContent(r, names, r.contents[id])), 0644); err != nil {
+			return fmt.Errorf("Error writing note: %w", err)
+		}
+	}
+
+	fmt.Printf("Exported %d notes to %s\n", len(r.contents), outDir)
+	return nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// matchingNotes returns the notes whose ID, alias or content contains
+// query, or only those whose titleOrSlug does when titleOnly is set.
+func matchingNotes(zettelHome, query string, ignoreCase, titleOnly bool) ([]string, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := readAliases(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+	aliased := map[string]bool{}
+	for alias, id := range aliases {
+		if containsQuery(alias, query, ignoreCase) {
+			aliased[id] = true
+		}
+	}
+
+	var matches []string
+	for _, id := range ids {
+		if !titleOnly && (aliased[id] || containsQuery(id, query, ignoreCase)) {
+			matches = append(matches, id)
+			continue
+		}
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return nil, err
+		}
+		if titleOnly {
+			content = titleOrSlug(content, id)
+		}
+		if containsQuery(content, query, ignoreCase) {
+			matches = append(matches, id)
+		}
+	}
+
+	return matches, nil
+}
+
+// subsequenceGaps reports whether the runes of query appear in order in s
+// and, if so, how many runes of s are skipped between the first and last
+// matched rune.
+func subsequenceGaps(query, s string) (int, bool) {
+	q := []rune(query)
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	gaps, i, started := 0, 0, false
+	for _, r := range s {
+		if r == q[i] {
+			started = true
+			if i++; i == len(q) {
+				return gaps, true
+			}
+		} else if started {
+			gaps++
+		}
+	}
+	return 0, false
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// fuzzyScore rates how well query matches a note ID; lower is better and
+// ok is false when it does not match at all. IDs containing query as a
+// subsequence always beat those only matching a word within a small edit
+// distance.
+func fuzzyScore(query, id string) (int, bool) {
+	query, id = strings.ToLower(query), strings.ToLower(id)
+	if gaps, ok := subsequenceGaps(query, id); ok {
+		return gaps, true
+	}
+
+	best := -1
+	words := strings.FieldsFunc(id, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for _, word := range words {
+		if d := levenshtein(query, word); best < 0 || d < best {
+			best = d
+		}
+	}
+	if best < 0 || best > len([]rune(query))/3 {
+		return 0, false
+	}
+	return 1000 + best, true
+}
+
+// fuzzyMatchingNotes ranks note IDs by fuzzyScore, breaking ties
+// alphabetically.
+func fuzzyMatchingNotes(zettelHome, query string) ([]string, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := map[string]int{}
+	var matches []string
+	for _, id := range ids {
+		if score, ok := fuzzyScore(query, id); ok {
+			scores[id] = score
+			matches = append(matches, id)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if scores[matches[i]] != scores[matches[j]] {
+			return scores[matches[i]] < scores[matches[j]]
+		}
+		return matches[i] < matches[j]
+	})
+
+	return matches, nil
+}
+
+// pickNote lets the user choose one of ids, with the interactive picker on a
+// terminal and by number otherwise.
+func pickNote(ids []string) (string, bool) {
+	if isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+		if id, ok, err := interactivePick(ids); err == nil {
+			return id, ok
+		}
+	}
+
+	for i, id := range ids {
+		fmt.Printf("%d) %s\n", i+1, id)
+	}
+	fmt.Print("Select a note: ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	n, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || n < 1 || n > len(ids) {
+		return "", false
+	}
+	return ids[n-1], true
+}
+
+// openOptions controls how openNotes matches notes.
+type openOptions struct {
+	ignoreCase bool
+	fuzzy      bool
+	titleOnly  bool
+	// all opens every match instead of asking for one.
+	all bool
+}
+
+// openAllWarn is how many notes open --all opens without asking first.
+const openAllWarn = 10
+
+// openNotes opens the note matching query, letting the user pick one when
+// there are several.
+func openNotes(zettelHome, query string, opts openOptions) error {
+	var matches []string
+	var err error
+	if opts.fuzzy {
+		matches, err = fuzzyMatchingNotes(zettelHome, query)
+	} else {
+		matches, err = matchingNotes(zettelHome, query, opts.ignoreCase, opts.titleOnly)
+	}
+	if err != nil {
+		return fmt.Errorf("Search error: %w", err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return notFoundf("No notes match: %v", query)
+	case 1:
+		return editNote(zettelHome, matches[0])
+	default:
+		if opts.all {
+			return editNotes(zettelHome, matches)
+		}
+		id, ok := pickNote(matches)
+		if !ok {
+			return errors.New("Invalid selection")
+		}
+		return editNote(zettelHome, id)
+	}
+}
+
+// editNotes opens several notes in the editor at once, asking first when
+// there are more than openAllWarn of them.
+func editNotes(zettelHome string, ids []string) error {
+	if len(ids) > openAllWarn && !confirm(fmt.Sprintf("Open %d notes?", len(ids))) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	paths := make([]string, len(ids))
+	for i, id := range ids {
+		paths[i] = notePath(zettelHome, id)
+	}
+	if err := openEditor(paths...); err != nil {
+		return fmt.Errorf("Error opening editor: %w", err)
+	}
+	updateSearchIndex(zettelHome)
+
+	for _, id := range ids {
+		if err := pushHistory(zettelHome, id); err != nil {
+			fmt.Println("Error writing history:", err)
+			return nil
+		}
+	}
+	return nil
+}
+
+// resolveIDPrefix returns the note whose ID or alias is prefix, or else the
+// one note whose ID starts with it. It returns "" when no note matches.
+func resolveIDPrefix(zettelHome, prefix string) (string, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(ids, prefix) {
+		return prefix, nil
+	}
+	aliases, err := readAliases(zettelHome)
+	if err != nil {
+		return "", err
+	}
+	if id, ok := lookupAlias(aliases, prefix); ok {
+		return id, nil
+	}
+
+	var matches []string
+	for _, id := range ids {
+		if strings.HasPrefix(id, prefix) {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%d note IDs start with %q: %s", len(matches), prefix, strings.Join(matches, ", "))
+	}
+}
+
+// openByID opens the note an ID prefix, or a copied [[link]], resolves to,
+// without searching note contents or asking which note to open.
+func openByID(zettelHome, arg string) error {
+	target, _, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(arg, "[["), "]]"), "|")
+	target, _, _ = strings.Cut(target, "#")
+
+	id, err := resolveIDPrefix(zettelHome, noteID(target))
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+	if id == "" {
+		return notFoundf("Note does not exist: %v", noteID(target))
+	}
+	return editNote(zettelHome, id)
+}
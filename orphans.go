@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// indexStartMarker opens the generated link list of an index note.
+const indexStartMarker = "<!-- zettel:index:start"
+
+func isIndexNote(content string) bool {
+	return strings.Contains(content, indexStartMarker)
+}
+
+// orphanNotes returns the notes with neither outgoing links nor backlinks.
+// Index notes are left out unless includeIndex is set.
+func orphanNotes(zettelHome string, includeIndex bool) ([]string, error) {
+	g, err := buildLinkGraph(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := map[string]bool{}
+	for src, targets := range g.links {
+		for _, dest := range targets {
+			linked[src] = true
+			linked[dest] = true
+		}
+	}
+
+	var orphans []string
+	for _, id := range g.ids {
+		if linked[id] {
+			continue
+		}
+		if !includeIndex {
+			content, err := readNote(zettelHome, id)
+			if err != nil {
+				return nil, err
+			}
+			if isIndexNote(content) {
+				continue
+			}
+		}
+		orphans = append(orphans, id)
+	}
+
+	return orphans, nil
+}
+
+func printOrphans(zettelHome string, includeIndex bool) error {
+	orphans, err := orphanNotes(zettelHome, includeIndex)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	for _, id := range orphans {
+		fmt.Println(id)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type heading struct {
+	level int
+	text  string
+}
+
+// parseHeadings returns the ATX headings of a note in order, skipping lines
+// inside fenced code blocks.
+func parseHeadings(content string) []heading {
+	_, body := parseFrontmatter(content)
+
+	var headings []heading
+	fence := ""
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		if h, ok := parseHeading(line); ok {
+			headings = append(headings, h)
+		}
+	}
+
+	return headings
+}
+
+// parseHeading parses line as an ATX heading.
+func parseHeading(line string) (heading, bool) {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return heading{}, false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return heading{}, false
+	}
+	return heading{level, strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))}, true
+}
+
+func printOutline(zettelHome, id string) error {
+	content, err := readNote(zettelHome, id)
+	if os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+
+	headings := parseHeadings(content)
+	top := 6
+	for _, h := range headings {
+		top = min(top, h.level)
+	}
+	for _, h := range headings {
+		fmt.Println(strings.Repeat("  ", h.level-top) + h.text)
+	}
+	return nil
+}
+
+// printAllOutlines lists every note's title followed by its second-level
+// headings.
+func printAllOutlines(zettelHome string) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+
+		fmt.Printf("%s: %s\n", id, noteTitle(content, id))
+		for _, h := range parseHeadings(content) {
+			if h.level == 2 {
+				fmt.Println("  " + h.text)
+			}
+		}
+	}
+	return nil
+}
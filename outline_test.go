@@ -0,0 +1,20 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHeadings(t *testing.T) {
+	content := "---\ntitle: Fenced\n---\n# Title\n\nText with a #tag\n\n## Part one ##\n###\tDetail\n" +
+		"```sh\n# not a heading\n```\n~~~\n## nor this\n~~~\n####### too deep\n#hashtag\n## Part two\n"
+	want := []heading{
+		{1, "Title"},
+		{2, "Part one"},
+		{3, "Detail"},
+		{2, "Part two"},
+	}
+	if got := parseHeadings(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHeadings = %v, want %v", got, want)
+	}
+}
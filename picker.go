@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const pickerHeight = 10
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stty runs stty against the terminal on stdin and returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// filterItems keeps the items containing filter, ignoring case.
+func filterItems(items []string, filter string) []string {
+	var matches []string
+	for _, item := range items {
+		if containsQuery(item, filter, true) {
+			matches = append(matches, item)
+		}
+	}
+	return matches
+}
+
+// readEscapeByte reads the byte following an Esc, giving up after a tenth of
+// a second so that a lone Esc does not wait for the rest of a sequence.
+func readEscapeByte(in *bufio.Reader) (byte, error) {
+	if in.Buffered() == 0 {
+		if _, err := stty("min", "0", "time", "1"); err != nil {
+			return 0, err
+		}
+		defer stty("min", "1", "time", "0")
+	}
+	return in.ReadByte()
+}
+
+// interactivePick shows a scrollable list of items that can be narrowed by
+// typing and navigated with the arrow keys; Esc and Ctrl-C cancel. It needs
+// stty to put the terminal into raw mode, with signals off so that Ctrl-C
+// reaches it and the terminal is always restored, and returns an error when
+// that is not possible.
+func interactivePick(items []string) (string, bool, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return "", false, err
+	}
+	defer stty(saved)
+
+	in := bufio.NewReader(os.Stdin)
+	filter, selected, offset, drawn := "", 0, 0, 0
+	defer func() {
+		if drawn > 0 {
+			fmt.Printf("\033[%dA\r\033[J", drawn)
+		}
+	}()
+
+	for {
+		matches := filterItems(items, filter)
+		selected = max(min(selected, len(matches)-1), 0)
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+pickerHeight {
+			offset = selected - pickerHeight + 1
+		}
+
+		if drawn > 0 {
+			fmt.Printf("\033[%dA", drawn)
+		}
+		fmt.Print("\r\033[J")
+		fmt.Printf("> %s\n", filter)
+		drawn = 1
+		for i := offset; i < len(matches) && i < offset+pickerHeight; i++ {
+			marker := "  "
+			if i == selected {
+				marker = "\033[7m> "
+			}
+			fmt.Printf("%s%s\033[0m\n", marker, matches[i])
+			drawn++
+		}
+
+		b, err := in.ReadByte()
+		if err != nil {
+			return "", false, err
+		}
+		switch b {
+		case '\r', '\n':
+			if len(matches) == 0 {
+				continue
+			}
+			return matches[selected], true, nil
+		case 3, 4:
+			return "", false, nil
+		case 127, 8:
+			if r := []rune(filter); len(r) > 0 {
+				filter = string(r[:len(r)-1])
+			}
+		case 27:
+			if next, err := readEscapeByte(in); err != nil || next != '[' {
+				return "", false, nil
+			}
+			switch key, _ := in.ReadByte(); key {
+			case 'A':
+				selected--
+			case 'B':
+				selected++
+			}
+		default:
+			if b >= ' ' {
+				in.UnreadByte()
+				r, _, _ := in.ReadRune()
+				filter += string(r)
+				selected = 0
+			}
+		}
+	}
+}
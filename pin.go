@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const pinnedFile = ".pinned"
+
+func readPinned(zettelHome string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(zettelHome, pinnedFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(content)), nil
+}
+
+func writePinned(zettelHome string, ids []string) error {
+	content := strings.Join(ids, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return stageWrite(zettelHome, filepath.Join(zettelHome, pinnedFile), []byte(content), "pinned notes")
+}
+
+// pinNote adds id to the pinned notes, or removes it when unpin is set.
+func pinNote(zettelHome, id string, unpin bool) error {
+	pinned, err := readPinned(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading pinned notes: %w", err)
+	}
+
+	index := -1
+	for i, p := range pinned {
+		if p == id {
+			index = i
+		}
+	}
+
+	switch {
+	case unpin && index < 0:
+		fmt.Println("Note is not pinned:", id)
+		return nil
+	case unpin:
+		pinned = append(pinned[:index], pinned[index+1:]...)
+	case index >= 0:
+		fmt.Println("Note is already pinned:", id)
+		return nil
+	default:
+		if _, err := os.Stat(notePath(zettelHome, id)); os.IsNotExist(err) {
+			return notFoundf("Note does not exist: %v", id)
+		}
+		pinned = append(pinned, id)
+	}
+
+	if err := writePinned(zettelHome, pinned); err != nil {
+		return fmt.Errorf("Error writing pinned notes: %w", err)
+	}
+	if unpin {
+		fmt.Println("Unpinned", id)
+	} else {
+		fmt.Println("Pinned", id)
+	}
+	return nil
+}
+
+// renamePinned keeps a pinned note pinned under its new ID.
+func renamePinned(zettelHome, oldID, newID string) error {
+	pinned, err := readPinned(zettelHome)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for i, id := range pinned {
+		if id == oldID {
+			pinned[i] = newID
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writePinned(zettelHome, pinned)
+}
+
+// listPinned prints the pinned notes that still exist, in the order they
+// were pinned.
+func listPinned(zettelHome string) error {
+	pinned, err := readPinned(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading pinned notes: %w", err)
+	}
+
+	for _, id := range pinned {
+		if _, err := os.Stat(notePath(zettelHome, id)); err == nil {
+			fmt.Println(id + noteExtension)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const progressBarWidth = 20
+
+// noteProgress reads the "goal" frontmatter field of a note and counts the
+// words in its body. ok is false when the note declares no goal.
+func noteProgress(zettelHome, id string) (words, goal int, ok bool, err error) {
+	content, err := os.ReadFile(filepath.Join(zettelHome, id+noteExtension))
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	fields, body := parseFrontmatter(string(content))
+	value, found := fields["goal"]
+	if !found {
+		return countWords(body), 0, false, nil
+	}
+
+	goal, err = strconv.Atoi(value)
+	if err != nil || goal <= 0 {
+		return 0, 0, false, fmt.Errorf("invalid goal %q in %s", value, id)
+	}
+
+	return countWords(body), goal, true, nil
+}
+
+func progressPercent(words, goal int) int {
+	return words * 100 / goal
+}
+
+func progressBar(words, goal int) string {
+	filled := words * progressBarWidth / goal
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", progressBarWidth-filled) + "]"
+}
+
+func printProgress(label string, words, goal int) {
+	fmt.Printf("%s %s %d/%d words (%d%%)\n", progressBar(words, goal), label, words, goal, progressPercent(words, goal))
+}
+
+// showProgress reports word count progress towards the goal of a single
+// note, or of every note declaring a goal when id is empty.
+func showProgress(zettelHome, id string) error {
+	if id != "" {
+		words, goal, ok, err := noteProgress(zettelHome, id)
+		if os.IsNotExist(err) {
+			return notFoundf("Note does not exist: %v", id)
+		} else if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		if !ok {
+			fmt.Printf("Note %s has no goal (%d words)\n", id, words)
+			return nil
+		}
+		printProgress(id, words, goal)
+		return nil
+	}
+
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	var totalWords, totalGoal int
+	for _, id := range ids {
+		words, goal, ok, err := noteProgress(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		printProgress(id, words, goal)
+		totalWords += words
+		totalGoal += goal
+	}
+
+	if totalGoal == 0 {
+		fmt.Println("No notes have a goal")
+		return nil
+	}
+	printProgress("total", totalWords, totalGoal)
+	return nil
+}
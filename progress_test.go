@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestProgressPercent(t *testing.T) {
+	tests := []struct {
+		words, goal, want int
+		bar               string
+	}{
+		{0, 100, 0, "[....................]"},
+		{50, 200, 25, "[#####...............]"},
+		{100, 100, 100, "[####################]"},
+		{150, 100, 150, "[####################]"},
+	}
+	for _, tt := range tests {
+		if got := progressPercent(tt.words, tt.goal); got != tt.want {
+			t.Errorf("progressPercent(%d, %d) = %d, want %d", tt.words, tt.goal, got, tt.want)
+		}
+		if got := progressBar(tt.words, tt.goal); got != tt.bar {
+			t.Errorf("progressBar(%d, %d) = %q, want %q", tt.words, tt.goal, got, tt.bar)
+		}
+	}
+}
+
+func TestNoteProgress(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"goal":    "---\ngoal: 10\n---\none two three four five\n",
+		"nogoal":  "one two three\n",
+		"badgoal": "---\ngoal: lots\n---\none\n",
+	})
+	tests := []struct {
+		id          string
+		words, goal int
+		ok, err     bool
+	}{
+		{"goal", 5, 10, true, false},
+		{"nogoal", 3, 0, false, false},
+		{"badgoal", 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		words, goal, ok, err := noteProgress(home, tt.id)
+		if words != tt.words || goal != tt.goal || ok != tt.ok || (err != nil) != tt.err {
+			t.Errorf("noteProgress(%s) = %d, %d, %v, %v; want %d, %d, %v, error %v",
+				tt.id, words, goal, ok, err, tt.words, tt.goal, tt.ok, tt.err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const publishNav = `<nav><a href="index.html">Index</a> · <a href="tags.html">Tags</a></nav>` + "\n"
+
+// publishedNote is a note as it appears on the published site. Archived
+// notes are published next to the others, so that [[links]] to them resolve
+// by ID alone.
+type publishedNote struct {
+	id      string
+	title   string
+	content string
+}
+
+// noteListHTML renders notes as a list of links to their pages.
+func noteListHTML(ids []string, notes map[string]publishedNote) string {
+	var b strings.Builder
+	b.WriteString("<ul>\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(noteHTMLName(id)), html.EscapeString(notes[id].title))
+	}
+	b.WriteString("</ul>\n")
+	return b.String()
+}
+
+// publishSite renders every note to outDir as a static site browsable from
+// the file system: a page per note with its backlinks, an index of all
+// notes and a page listing the notes of each tag.
+func publishSite(zettelHome, outDir, cssPath string, includeArchived bool) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+	if includeArchived {
+		archived, err := listArchivedIDs(zettelHome)
+		if err != nil {
+			return fmt.Errorf("Error listing notes: %w", err)
+		}
+		ids = append(ids, archived...)
+	}
+	css, err := readStylesheet(cssPath)
+	if err != nil {
+		return err
+	}
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	notes := map[string]publishedNote{}
+	var order []string
+	for _, path := range ids {
+		content, err := readNote(zettelHome, path)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		id := filepath.Base(path)
+		if _, ok := notes[id]; ok {
+			continue
+		}
+		notes[id] = publishedNote{id, noteTitle(content, id), content}
+		order = append(order, id)
+	}
+	sort.Strings(order)
+
+	incoming := map[string][]string{}
+	tagged := map[string][]string{}
+	for _, id := range order {
+		seen := map[string]bool{}
+		for _, target := range parseLinks(notes[id].content) {
+			if resolved, _ := r.resolve(target); resolved != "" {
+				target = filepath.Base(resolved)
+			}
+			if _, ok := notes[target]; ok && target != id && !seen[target] {
+				seen[target] = true
+				incoming[target] = append(incoming[target], id)
+			}
+		}
+		for _, tag := range noteTags(notes[id].content) {
+			tagged[tag] = append(tagged[tag], id)
+		}
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("Error creating directory: %w", err)
+	}
+	writePage := func(name, title, body string) error {
+		f, err := os.Create(filepath.Join(outDir, name))
+		if err != nil {
+			return fmt.Errorf("Error creating file: %w", err)
+		}
+		defer f.Close()
+		if err := writeHTMLPage(f, title, body, css); err != nil {
+			return fmt.Errorf("Error writing HTML: %w", err)
+		}
+		return nil
+	}
+
+	for _, id := range order {
+		note := notes[id]
+		_, body := parseFrontmatter(note.content)
+		page := publishNav + markdownToHTML(body, wikiLinkHref(r))
+		if sources := incoming[id]; len(sources) > 0 {
+			page += "<section class=\"backlinks\">\n<h2>Backlinks</h2>\n" + noteListHTML(sources, notes) + "</section>\n"
+		}
+		if err := writePage(noteHTMLName(id), note.title, page); err != nil {
+			return err
+		}
+	}
+
+	if err := writePage("index.html", "Index", publishNav+"<h1>Index</h1>\n"+noteListHTML(order, notes)); err != nil {
+		return err
+	}
+
+	tags := make([]string, 0, len(tagged))
+	for tag := range tagged {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	var b strings.Builder
+	b.WriteString(publishNav + "<h1>Tags</h1>\n")
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "<h2 id=\"tag-%s\">#%s</h2>\n", html.EscapeString(tag), html.EscapeString(tag))
+		b.WriteString(noteListHTML(tagged[tag], notes))
+	}
+	if err := writePage("tags.html", "Tags", b.String()); err != nil {
+		return err
+	}
+
+	fmt.Printf("Published %d notes to %s\n", len(order), outDir)
+	return nil
+}
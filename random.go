@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// openRandomNote opens a uniformly chosen note, optionally only among those
+// carrying tag. The global math/rand source is seeded randomly at startup,
+// so consecutive runs pick independently.
+func openRandomNote(zettelHome, tag string) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	if tag != "" {
+		tagged := ids[:0]
+		for _, id := range ids {
+			content, err := readNote(zettelHome, id)
+			if err != nil {
+				return fmt.Errorf("Error reading note: %w", err)
+			}
+			if hasTags(noteTags(content), []string{tag}, false) {
+				tagged = append(tagged, id)
+			}
+		}
+		ids = tagged
+	}
+
+	if len(ids) == 0 {
+		if tag != "" {
+			return notFoundf("No notes tagged #%s to choose from", tag)
+		}
+		return notFoundf("No notes to choose from yet")
+	}
+
+	return editNote(zettelHome, ids[rand.Intn(len(ids))])
+}
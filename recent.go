@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// relativeTime describes how long before now t was, as in "2 hours ago".
+func relativeTime(t, now time.Time) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit + " ago"
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month")
+	default:
+		return plural(int(d/(365*24*time.Hour)), "year")
+	}
+}
+
+// listRecentNotes prints the n most recently modified notes, newest first.
+func listRecentNotes(zettelHome string, n int) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	modTimes := make(map[string]time.Time, len(ids))
+	for _, id := range ids {
+		info, err := os.Stat(notePath(zettelHome, id))
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		modTimes[id] = info.ModTime()
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return modTimes[ids[i]].After(modTimes[ids[j]]) })
+	ids = ids[:min(n, len(ids))]
+
+	if jsonOutput {
+		type recentResult struct {
+			ID       string    `json:"id"`
+			Filename string    `json:"filename"`
+			Modified time.Time `json:"modified"`
+		}
+		results := []recentResult{}
+		for _, id := range ids {
+			results = append(results, recentResult{id, id + noteExtension, modTimes[id]})
+		}
+		return printJSON(results)
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, id := range ids {
+		t := modTimes[id]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", id+noteExtension, t.Format("2006-01-02 15:04"), relativeTime(t, now))
+	}
+	w.Flush()
+	return nil
+}
+
+// touchNote sets the modification time of a note to t, so that it sorts as
+// recently modified without changing its content.
+func touchNote(zettelHome, id string, t time.Time) error {
+	path := notePath(zettelHome, id)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	}
+
+	if err := os.Chtimes(path, t, t); err != nil {
+		return fmt.Errorf("Error touching note: %w", err)
+	}
+	updateSearchIndex(zettelHome)
+	return nil
+}
+
+// parseSince reads the start of a log window: a number of days, weeks or
+// months before now such as "7d", "2w" or "3m", or a date such as
+// "2024-01-31".
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	invalid := fmt.Errorf("invalid time %q: use a number followed by d, w or m, or a date such as 2024-01-31", value)
+	if len(value) < 2 {
+		return time.Time{}, invalid
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, invalid
+	}
+	switch value[len(value)-1] {
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	}
+	return time.Time{}, invalid
+}
+
+// parseUntil reads the end of a window like parseSince, except that a date
+// such as "2024-01-31" includes the whole day.
+func parseUntil(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+	return parseSince(value, now)
+}
+
+// logNotes prints the notes created since the given time according to the
+// timestamp in their IDs, newest first.
+func logNotes(zettelHome string, since time.Time) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	created := map[string]time.Time{}
+	var logged []string
+	for _, id := range ids {
+		if t, ok := noteCreated(id); ok && !t.Before(since) {
+			created[id] = t
+			logged = append(logged, id)
+		}
+	}
+	sort.SliceStable(logged, func(i, j int) bool { return created[logged[i]].After(created[logged[j]]) })
+
+	if jsonOutput {
+		type logResult struct {
+			ID       string    `json:"id"`
+			Filename string    `json:"filename"`
+			Created  time.Time `json:"created"`
+		}
+		results := []logResult{}
+		for _, id := range logged {
+			results = append(results, logResult{id, id + noteExtension, created[id]})
+		}
+		return printJSON(results)
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, id := range logged {
+		t := created[id]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", id+noteExtension, t.Format("2006-01-02 15:04"), relativeTime(t, now))
+	}
+	w.Flush()
+	return nil
+}
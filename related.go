@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type relatedNote struct {
+	id     string
+	shared int
+	score  float64
+}
+
+// jaccard returns the overlap of two sets as |a∩b| / |a∪b| along with the
+// size of the intersection.
+func jaccard(a, b map[string]bool) (float64, int) {
+	shared := 0
+	for key := range a {
+		if b[key] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0, 0
+	}
+	return float64(shared) / float64(union), shared
+}
+
+// relatedNotes ranks every other note by the overlap of its tags (and, if
+// useLinks is set, its link neighbours) with those of id.
+func relatedNotes(zettelHome, id string, useLinks bool) ([]relatedNote, error) {
+	g, err := buildLinkGraph(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := map[string]map[string]bool{}
+	if useLinks {
+		for src, targets := range g.links {
+			for _, dest := range targets {
+				if neighbors[src] == nil {
+					neighbors[src] = map[string]bool{}
+				}
+				if neighbors[dest] == nil {
+					neighbors[dest] = map[string]bool{}
+				}
+				neighbors[src]["[["+dest+"]]"] = true
+				neighbors[dest]["[["+src+"]]"] = true
+			}
+		}
+	}
+
+	placeholder := placeholderTag()
+	features := map[string]map[string]bool{}
+	for _, other := range g.ids {
+		content, err := readNote(zettelHome, other)
+		if err != nil {
+			return nil, err
+		}
+		set := map[string]bool{}
+		for _, tag := range noteTags(content) {
+			if tag != placeholder {
+				set["#"+tag] = true
+			}
+		}
+		for key := range neighbors[other] {
+			set[key] = true
+		}
+		features[other] = set
+	}
+
+	var related []relatedNote
+	for _, other := range g.ids {
+		if other == id {
+			continue
+		}
+		score, shared := jaccard(features[id], features[other])
+		if shared > 0 {
+			related = append(related, relatedNote{id: other, shared: shared, score: score})
+		}
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].score != related[j].score {
+			return related[i].score > related[j].score
+		}
+		return related[i].id < related[j].id
+	})
+
+	return related, nil
+}
+
+func showRelated(zettelHome, id string, useLinks bool, limit, linkIt int) error {
+	srcPath := filepath.Join(zettelHome, id+noteExtension)
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	}
+
+	related, err := relatedNotes(zettelHome, id, useLinks)
+	if err != nil {
+		return fmt.Errorf("Error finding related notes: %w", err)
+	}
+
+	if limit > 0 && len(related) > limit {
+		related = related[:limit]
+	}
+	for _, r := range related {
+		fmt.Printf("%.2f\t%d shared\t%s\n", r.score, r.shared, r.id)
+	}
+
+	if linkIt > len(related) {
+		linkIt = len(related)
+	}
+	linked := 0
+	for _, r := range related[:max(linkIt, 0)] {
+		added, err := appendLinkOnce(zettelHome, srcPath, r.id)
+		if err != nil {
+			return fmt.Errorf("Error writing link: %w", err)
+		}
+		if added {
+			fmt.Printf("Linked %s -> %s\n", id, r.id)
+			linked++
+		}
+	}
+	if linked > 0 {
+		recordChange(zettelHome, fmt.Sprintf("link %s to %d related notes", id, linked))
+	}
+	return nil
+}
+
+// minSuggestShared is how many tags a note must share with another to be
+// suggested as a link.
+const minSuggestShared = 2
+
+// suggestLinks lists the notes sharing at least minSuggestShared tags with
+// id that it does not link to yet, by ID, slug, alias or title, most shared
+// tags first, and after confirmation appends links to the top linkTop of
+// them.
+func suggestLinks(zettelHome, id string, linkTop int) error {
+	content, err := readNote(zettelHome, id)
+	if os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+
+	related, err := relatedNotes(zettelHome, id, false)
+	if err != nil {
+		return fmt.Errorf("Error finding related notes: %w", err)
+	}
+
+	resolver, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	var suggestions []relatedNote
+	for _, r := range related {
+		if r.shared >= minSuggestShared && !resolver.linksTo(content, r.id) {
+			suggestions = append(suggestions, r)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].shared > suggestions[j].shared
+	})
+
+	if len(suggestions) == 0 {
+		fmt.Println("No link suggestions for", id)
+		return nil
+	}
+	for _, s := range suggestions {
+		fmt.Printf("%d shared tags\t%s\n", s.shared, s.id)
+	}
+
+	linkTop = min(linkTop, len(suggestions))
+	if linkTop <= 0 || !confirm(fmt.Sprintf("Link %s to the top %d?", id, linkTop)) {
+		return nil
+	}
+	for _, s := range suggestions[:linkTop] {
+		if err := appendLink(zettelHome, notePath(zettelHome, id), s.id); err != nil {
+			return fmt.Errorf("Error writing link: %w", err)
+		}
+		fmt.Printf("Linked %s -> %s\n", id, s.id)
+	}
+	recordChange(zettelHome, fmt.Sprintf("link %s to %d suggested notes", id, linkTop))
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var relatedTestNotes = map[string]string{
+	"a": "# A\n\n#x #y #z\n",
+	"b": "# B\n\n#x #y #z\n",
+	"c": "# C\n\n#x #y\n",
+	"d": "# D\n\n#x #w\n",
+	"e": "# E\n\n#w\n",
+	"f": "# F\n\n#tagme #x\n",
+}
+
+func TestRelatedNotes(t *testing.T) {
+	related, err := relatedNotes(testVault(t, relatedTestNotes), "a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []relatedNote{
+		{"b", 3, 1},
+		{"c", 2, 2.0 / 3},
+		{"f", 1, 1.0 / 3},
+		{"d", 1, 1.0 / 4},
+	}
+	if len(related) != len(want) {
+		t.Fatalf("related = %v, want %v", related, want)
+	}
+	for i := range want {
+		if related[i] != want[i] {
+			t.Errorf("related[%d] = %v, want %v", i, related[i], want[i])
+		}
+	}
+}
+
+func TestRelatedLinkIt(t *testing.T) {
+	home := testVault(t, relatedTestNotes)
+	for range 2 {
+		if out, code := runZettel(t, home, "related", "a", "--link-it", "2"); code != 0 {
+			t.Fatalf("related --link-it exited %d: %s", code, out)
+		}
+	}
+	content := readTestNote(t, home, "a")
+	for _, link := range []string{"[[b]]", "[[c]]"} {
+		if n := strings.Count(content, link); n != 1 {
+			t.Errorf("a has %d %s links, want 1:\n%s", n, link, content)
+		}
+	}
+	if strings.Contains(content, "[[f]]") {
+		t.Errorf("a links beyond the top 2:\n%s", content)
+	}
+}
+
+func TestSuggestSkipsLinkedNotes(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"20240101120000-src":        "# Src\n\n[[Beta Notes]] [[gamma]]\n\n#a #b\n",
+		"20240102120000-beta-notes": "# Beta Notes\n\n#a #b\n",
+		"20240103120000-gamma":      "# Gamma\n\n#a #b\n",
+		"20240104120000-delta":      "# Delta\n\n#a #b\n",
+	})
+	out, code := runZettel(t, home, "suggest", "20240101120000-src")
+	if code != 0 || out != "2 shared tags\t20240104120000-delta\n" {
+		t.Errorf("suggest exited %d printing %q, want only delta", code, out)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// renamedID keeps the timestamp prefix of id and replaces the rest with the
+// slug of title.
+func renamedID(id, title string) string {
+	slug := titleSlug(title)
+	if slug == "" {
+		slug = slugify(title)
+	}
+	if prefix, _, ok := idTimestamp(id); ok {
+		return prefix + "-" + slug
+	}
+	return slug
+}
+
+// relinkNotes points every link that resolves to oldID across the vault at
+// newID, as rewriteLinks does, and reports how many links in how many notes
+// were rewritten.
+func relinkNotes(zettelHome, oldID, newID string) (links, notes int, err error) {
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return 0, 0, err
+	}
+	next := r.moved(oldID, newID)
+
+	for _, id := range sortedKeys(r.contents) {
+		updated, n := rewriteLinks(r.contents[id], r, next, oldID, newID)
+		if n == 0 {
+			continue
+		}
+		if err := stageWrite(zettelHome, notePath(zettelHome, id), []byte(updated), fmt.Sprintf("rewrite %d links", n)); err != nil {
+			return links, notes, err
+		}
+		links += n
+		notes++
+	}
+
+	return links, notes, nil
+}
+
+// moveNote renames the note oldID to newID and updates the links, pin and
+// aliases to it, reporting how many links in how many notes were rewritten.
+func moveNote(zettelHome, oldID, newID string) (links, notes int, err error) {
+	if links, notes, err = relinkNotes(zettelHome, oldID, newID); err != nil {
+		return links, notes, err
+	}
+	if err := stageRename(zettelHome, notePath(zettelHome, oldID), notePath(zettelHome, newID)); err != nil {
+		return links, notes, err
+	}
+	if err := renamePinned(zettelHome, oldID, newID); err != nil {
+		return links, notes, err
+	}
+	return links, notes, retargetAliases(zettelHome, oldID, newID)
+}
+
+func renameNote(zettelHome, oldID, title string) error {
+	oldPath := filepath.Join(zettelHome, oldID+noteExtension)
+	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", oldID)
+	}
+
+	newID := renamedID(oldID, title)
+	if newID == oldID {
+		fmt.Println("Note already has that name:", oldID)
+		return nil
+	}
+
+	newPath := filepath.Join(zettelHome, newID+noteExtension)
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("Note already exists: %v", newID)
+	}
+
+	links, notes, err := moveNote(zettelHome, oldID, newID)
+	if err != nil {
+		return fmt.Errorf("Error renaming note: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Would rename %s -> %s, updating %d links in %d notes\n", oldID, newID, links, notes)
+		return nil
+	}
+	recordChange(zettelHome, "rename "+oldID+" -> "+newID)
+	fmt.Printf("Renamed %s -> %s, updated %d links in %d notes\n", oldID, newID, links, notes)
+	return nil
+}
+
+// conformingID reports whether id follows one of the schemes zettel names
+// notes with: a timestamp optionally followed by "-slug", or a daily
+// journal ID.
+func conformingID(id string) bool {
+	if day, ok := strings.CutSuffix(id, "-daily"); ok {
+		if _, err := time.Parse("20060102", day); err == nil {
+			return true
+		}
+	}
+
+	prefix, _, ok := idTimestamp(id)
+	if !ok {
+		return false
+	}
+	rest := id[len(prefix):]
+	return rest == "" || (len(rest) > 1 && rest[0] == '-' && rest == slugify(rest))
+}
+
+// normalizedID suggests a conforming ID for a note, stamped with its
+// modification time and slugged from its title or current name, that no
+// other note has.
+func normalizedID(zettelHome, id string) (string, error) {
+	info, err := os.Stat(notePath(zettelHome, id))
+	if err != nil {
+		return "", err
+	}
+	content, err := readNote(zettelHome, id)
+	if err != nil {
+		return "", err
+	}
+
+	base := info.ModTime().Format(cfg.IDFormat)
+	if slug := titleSlug(noteTitle(content, id)); slug != "" {
+		base += "-" + slug
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(notePath(zettelHome, candidate)); os.IsNotExist(err) {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// checkNoteNames reports the notes whose names do not follow the ID scheme,
+// with a suggested name, renaming them and their links when fix is set.
+func checkNoteNames(zettelHome string, fix bool) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	renamed := 0
+	for _, id := range ids {
+		if conformingID(id) {
+			continue
+		}
+		newID, err := normalizedID(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		if !fix {
+			fmt.Printf("%s: nonconforming name, suggest %s\n", id+noteExtension, newID+noteExtension)
+			continue
+		}
+
+		links, notes, err := moveNote(zettelHome, id, newID)
+		if err != nil {
+			return fmt.Errorf("Error renaming note: %w", err)
+		}
+		if dryRun {
+			fmt.Printf("Would rename %s -> %s, updating %d links in %d notes\n", id, newID, links, notes)
+		} else {
+			fmt.Printf("Renamed %s -> %s, updated %d links in %d notes\n", id, newID, links, notes)
+		}
+		renamed++
+	}
+
+	if renamed > 0 && !dryRun {
+		recordChange(zettelHome, fmt.Sprintf("normalize %d note names", renamed))
+	}
+	return nil
+}
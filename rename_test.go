@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConformingID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"20240315093000", true},
+		{"20240315093000-meeting-notes", true},
+		{"202403150930-meeting-notes", true},
+		{"20240315-daily", true},
+		{"20240315093000-Meeting-Notes", true},
+		{"20240315093000-meeting notes", false},
+		{"20240315093000-", false},
+		{"20240315093000meeting", false},
+		{"20241315-daily", false},
+		{"meeting-notes", false},
+		{"2024-03-15", false},
+	}
+	for _, tt := range tests {
+		if got := conformingID(tt.id); got != tt.want {
+			t.Errorf("conformingID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizedID(t *testing.T) {
+	defer func(c config) { cfg = c }(cfg)
+	home := testVault(t, map[string]string{
+		"Meeting Notes": "# Weekly sync: Q2\n",
+		"untitled":      "no heading\n",
+		"2024-03-15":    "# Weekly sync: Q2\n",
+	})
+	modified := time.Date(2024, 3, 15, 9, 30, 0, 0, time.Local)
+	for _, id := range []string{"Meeting Notes", "untitled", "2024-03-15"} {
+		if err := os.Chtimes(notePath(home, id), modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		format, id, want string
+	}{
+		{idLayouts[0], "Meeting Notes", "20240315093000-weekly-sync-q2"},
+		{idLayouts[0], "untitled", "20240315093000-untitled"},
+		{"200601021504", "Meeting Notes", "202403150930-weekly-sync-q2"},
+	}
+	for _, tt := range tests {
+		cfg.IDFormat = tt.format
+		got, err := normalizedID(home, tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || !conformingID(got) {
+			t.Errorf("normalizedID(%q) with id_format %q = %q, want %q", tt.id, tt.format, got, tt.want)
+		}
+	}
+
+	cfg.IDFormat = idLayouts[0]
+	if err := os.WriteFile(notePath(home, "20240315093000-weekly-sync-q2"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := normalizedID(home, "2024-03-15"); got != "20240315093000-weekly-sync-q2-2" {
+		t.Errorf("normalizedID with a taken name = %q", got)
+	}
+}
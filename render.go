@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingRegex     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	listItemRegex    = regexp.MustCompile(`^\s{0,3}([-*+]|\d+[.)])\s+(.*)$`)
+	ruleRegex        = regexp.MustCompile(`^\s{0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	htmlWikiRegex    = regexp.MustCompile(`\[\[([^\]|#]*)(#[^\]|]*)?(?:\|([^\]]*))?\]\]`)
+	htmlImageRegex   = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]*)\)`)
+	htmlLinkRegex    = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)\)`)
+	htmlStrongRegex  = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	htmlEmRegex      = regexp.MustCompile(`\*([^*]+)\*|(^|[^\p{L}\p{N}_])_([^_]+)_`)
+	htmlStrikeRegex  = regexp.MustCompile(`~~([^~]+)~~`)
+	htmlTagSpanRegex = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_-]+(?:/[\p{L}\p{N}_-]+)*)`)
+)
+
+// noteHTMLName is the file a note is rendered to, which wikilinks point at.
+func noteHTMLName(id string) string {
+	return id + ".html"
+}
+
+// wikiLinkHref returns the page a [[target]] links to: that of the note r
+// resolves it to, or of target itself when it resolves to none. Archived
+// notes are published next to the others.
+func wikiLinkHref(r *linkResolver) func(string) string {
+	return func(target string) string {
+		if id, _ := r.resolve(target); id != "" {
+			return noteHTMLName(filepath.Base(id))
+		}
+		return noteHTMLName(target)
+	}
+}
+
+// renderInline converts the inline markdown of one block of text to HTML:
+// code spans, links, wikilinks, emphasis and #tags. href gives the page
+// each wikilink target points at.
+func renderInline(text string, href func(string) string) string {
+	var b strings.Builder
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		// Odd parts are inside a code span, unless the last backtick is
+		// unmatched.
+		if i%2 == 1 && i < len(parts)-1 {
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			b.WriteString("`")
+		}
+
+		part = html.EscapeString(part)
+		part = htmlWikiRegex.ReplaceAllStringFunc(part, func(m string) string {
+			sub := htmlWikiRegex.FindStringSubmatch(m)
+			target := noteID(strings.TrimSpace(sub[1]))
+			label := sub[3]
+			if label == "" {
+				label = sub[1] + sub[2]
+			}
+			return `<a class="wikilink" href="` + html.EscapeString(href(html.UnescapeString(target))) + sub[2] + `">` + label + "</a>"
+		})
+		part = htmlImageRegex.ReplaceAllString(part, `<img src="$2" alt="$1">`)
+		part = htmlLinkRegex.ReplaceAllString(part, `<a href="$2">$1</a>`)
+		part = htmlStrongRegex.ReplaceAllString(part, "<strong>$1$2</strong>")
+		part = htmlEmRegex.ReplaceAllStringFunc(part, func(m string) string {
+			sub := htmlEmRegex.FindStringSubmatch(m)
+			if sub[1] != "" {
+				return "<em>" + sub[1] + "</em>"
+			}
+			return sub[2] + "<em>" + sub[3] + "</em>"
+		})
+		part = htmlStrikeRegex.ReplaceAllString(part, "<del>$1</del>")
+		part = htmlTagSpanRegex.ReplaceAllString(part, `$1<span class="tag">#$2</span>`)
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// markdownToHTML converts the markdown of a note body to HTML. It covers the
+// subset notes use: headings, paragraphs, lists, block quotes, rules and
+// fenced code blocks, plus the inline syntax of renderInline.
+func markdownToHTML(markdown string, href func(string) string) string {
+	var b strings.Builder
+	var paragraph []string
+	listTag := ""
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + renderInline(strings.Join(paragraph, "\n"), href) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if listTag != "" {
+			b.WriteString("</" + listTag + ">\n")
+			listTag = ""
+		}
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if inCode {
+			if strings.HasPrefix(trimmed, "```") {
+				b.WriteString("</code></pre>\n")
+				inCode = false
+			} else {
+				b.WriteString(html.EscapeString(line) + "\n")
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			closeList()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			if lang != "" {
+				b.WriteString(`<pre><code class="language-` + html.EscapeString(lang) + `">`)
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			inCode = true
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case strings.HasPrefix(trimmed, "<!--") && strings.HasSuffix(trimmed, "-->"):
+			// Comments such as the index markers are not shown.
+		case headingRegex.MatchString(trimmed):
+			flushParagraph()
+			closeList()
+			m := headingRegex.FindStringSubmatch(trimmed)
+			level := len(m[1])
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, renderInline(m[2], href), level)
+		case ruleRegex.MatchString(line):
+			flushParagraph()
+			closeList()
+			b.WriteString("<hr>\n")
+		case listItemRegex.MatchString(line):
+			flushParagraph()
+			m := listItemRegex.FindStringSubmatch(line)
+			tag := "ul"
+			if m[1][0] >= '0' && m[1][0] <= '9' {
+				tag = "ol"
+			}
+			if tag != listTag {
+				closeList()
+				b.WriteString("<" + tag + ">\n")
+				listTag = tag
+			}
+			b.WriteString("<li>" + renderInline(m[2], href) + "</li>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			closeList()
+			quote := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			b.WriteString("<blockquote><p>" + renderInline(quote, href) + "</p></blockquote>\n")
+		default:
+			closeList()
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	if inCode {
+		b.WriteString("</code></pre>\n")
+	}
+	flushParagraph()
+	closeList()
+
+	return b.String()
+}
+
+// writeHTMLPage writes a complete HTML document around body, inlining css
+// in a <style> element when it is not empty.
+func writeHTMLPage(w io.Writer, title, body, css string) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	if css != "" {
+		b.WriteString("<style>\n" + strings.TrimRight(css, "\n") + "\n</style>\n")
+	}
+	b.WriteString("</head>\n<body>\n")
+	b.WriteString(body)
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// readStylesheet returns the contents of the stylesheet at path, or "" when
+// path is empty.
+func readStylesheet(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Error reading stylesheet: %w", err)
+	}
+	return string(data), nil
+}
+
+// renderNote converts a note to HTML, writing it to out or stdout.
+func renderNote(zettelHome, id, out, cssPath string) error {
+	content, err := readNote(zettelHome, id)
+	if os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+
+	css, err := readStylesheet(cssPath)
+	if err != nil {
+		return err
+	}
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	w := os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return fmt.Errorf("Error creating file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	_, body := parseFrontmatter(content)
+	if err := writeHTMLPage(w, noteTitle(content, id), markdownToHTML(body, wikiLinkHref(r)), css); err != nil {
+		return fmt.Errorf("Error writing HTML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type noteChange struct {
+	path        string
+	old, new    string
+	occurrences int
+}
+
+// printChangeDiff prints the lines of a note that a change rewrites.
+func printChangeDiff(zettelHome string, c noteChange) {
+	rel, _ := filepath.Rel(zettelHome, c.path)
+	fmt.Printf("--- %s\n", rel)
+
+	oldLines := strings.Split(c.old, "\n")
+	newLines := strings.Split(c.new, "\n")
+	if len(oldLines) != len(newLines) {
+		for _, line := range oldLines {
+			fmt.Println("-" + line)
+		}
+		for _, line := range newLines {
+			fmt.Println("+" + line)
+		}
+		return
+	}
+
+	for i := range oldLines {
+		if oldLines[i] != newLines[i] {
+			fmt.Printf("%d:-%s\n", i+1, oldLines[i])
+			fmt.Printf("%d:+%s\n", i+1, newLines[i])
+		}
+	}
+}
+
+// replaceInNotes replaces query with replacement in every note outside
+// hidden directories such as .templates and .git, showing a diff per
+// affected note and asking before writing. Under --dry-run it stops after
+// the diffs.
+func replaceInNotes(zettelHome, query, replacement string, useRegex, ignoreCase bool) error {
+	if ignoreCase {
+		if !useRegex {
+			query = regexp.QuoteMeta(query)
+			replacement = strings.ReplaceAll(replacement, "$", "$$")
+		}
+		query = "(?i)" + query
+		useRegex = true
+	}
+
+	var re *regexp.Regexp
+	if useRegex {
+		var err error
+		if re, err = regexp.Compile(query); err != nil {
+			return usagef("Invalid regular expression: %v", err)
+		}
+	}
+
+	var changes []noteChange
+	err := filepath.Walk(zettelHome, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != zettelHome && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if isIgnored(zettelHome, path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || filepath.Ext(path) != noteExtension {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		old := string(content)
+		c := noteChange{path: path, old: old}
+		if re != nil {
+			c.occurrences = len(re.FindAllStringIndex(old, -1))
+			c.new = re.ReplaceAllString(old, replacement)
+		} else {
+			c.occurrences = strings.Count(old, query)
+			c.new = strings.ReplaceAll(old, query, replacement)
+		}
+		if c.occurrences > 0 && c.new != old {
+			changes = append(changes, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Search error: %w", err)
+	}
+
+	total := 0
+	for _, c := range changes {
+		printChangeDiff(zettelHome, c)
+		total += c.occurrences
+	}
+	fmt.Printf("%d occurrences in %d notes\n", total, len(changes))
+
+	if dryRun || len(changes) == 0 {
+		return nil
+	}
+	if !confirm("Apply changes?") {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	for _, c := range changes {
+		if err := writeFileAtomic(c.path, []byte(c.new)); err != nil {
+			return fmt.Errorf("Error writing note: %w", err)
+		}
+	}
+	recordChange(zettelHome, fmt.Sprintf("replace %q with %q", query, replacement))
+	fmt.Printf("Replaced %d occurrences in %d notes\n", total, len(changes))
+	return nil
+}
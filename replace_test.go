@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplaceInNotes(t *testing.T) {
+	notes := map[string]string{
+		"a":               "colour, Colour and colours\n",
+		"b":               "nothing here\n",
+		".templates/meet": "colour\n",
+	}
+	tests := []struct {
+		name                 string
+		query, replacement   string
+		useRegex, ignoreCase bool
+		want                 string
+	}{
+		{"literal", "colour", "color", false, false, "color, Colour and colors\n"},
+		{"ignore case", "colour", "color", false, true, "color, color and colors\n"},
+		{"literal dollar", "colour", "$1", false, true, "$1, $1 and $1s\n"},
+		{"regex", `\bcolour\b`, "color", true, false, "color, Colour and colours\n"},
+		{"regex groups", `(?i)(c)olour(s?)`, "${1}olor$2", true, false, "color, Color and colors\n"},
+	}
+
+	oldYes := assumeYes
+	assumeYes = true
+	t.Cleanup(func() { assumeYes = oldYes })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := testVault(t, notes)
+			replaceInNotes(home, tt.query, tt.replacement, tt.useRegex, tt.ignoreCase)
+			if got := readTestNote(t, home, "a"); got != tt.want {
+				t.Errorf("a = %q, want %q", got, tt.want)
+			}
+			for _, id := range []string{"b", ".templates/meet"} {
+				if got := readTestNote(t, home, id); got != notes[id] {
+					t.Errorf("%s = %q, want it unchanged", id, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceConfirmation(t *testing.T) {
+	const original = "teh cat\n"
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"without a terminal", []string{"search", "teh", "--replace", "the"}, original},
+		{"dry run", []string{"--dry-run", "search", "teh", "--replace", "the"}, original},
+		{"yes", []string{"--yes", "search", "teh", "--replace", "the"}, "the cat\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := testVault(t, map[string]string{"a": original})
+			out, code := runZettel(t, home, tt.args...)
+			if code != 0 {
+				t.Fatalf("exited %d: %s", code, out)
+			}
+			if !strings.Contains(out, "1:-teh cat\n1:+the cat\n") {
+				t.Errorf("printed %q, want the diff", out)
+			}
+			if got := readTestNote(t, home, "a"); got != tt.want {
+				t.Errorf("a = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
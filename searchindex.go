@@ -0,0 +1,308 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// searchIndexFile holds the inverted index search uses to avoid reading
+// every note. It covers the notes directly in the notes directory, not
+// archived ones.
+const searchIndexFile = ".index"
+
+// indexedNote records the state of a note when it was indexed, to tell
+// whether the index is stale.
+type indexedNote struct {
+	modTime int64
+	size    int64
+}
+
+type searchIndex struct {
+	notes map[string]indexedNote
+	// tokens maps each lower-cased word to the sorted IDs of the notes
+	// containing it.
+	tokens map[string][]string
+}
+
+// tokenize splits text into its lower-cased runs of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// The index file starts with a version line and the number of notes,
+// followed by a "<id>\t<mtime>\t<size>" line per note and then a
+// "<word>\t<n> <n>..." line per word listing the positions of the notes
+// containing it. Words come last so that a search can skip the postings of
+// words it does not need.
+const searchIndexHeader = "zettel-index 1"
+
+// readSearchIndex parses the index file, calling wanted with each word to
+// decide whether to decode its postings; a nil wanted decodes them all.
+func readSearchIndex(zettelHome string, wanted func(word string) bool) (*searchIndex, error) {
+	data, err := os.ReadFile(filepath.Join(zettelHome, searchIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) < 2 || lines[0] != searchIndexHeader {
+		return nil, errors.New("unsupported search index")
+	}
+	n, err := strconv.Atoi(lines[1])
+	if err != nil || n < 0 || len(lines) < 2+n {
+		return nil, errors.New("corrupt search index")
+	}
+
+	idx := &searchIndex{notes: make(map[string]indexedNote, n), tokens: map[string][]string{}}
+	ids := make([]string, n)
+	for i, line := range lines[2 : 2+n] {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, errors.New("corrupt search index")
+		}
+		modTime, err1 := strconv.ParseInt(fields[1], 10, 64)
+		size, err2 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("corrupt search index")
+		}
+		ids[i] = fields[0]
+		idx.notes[fields[0]] = indexedNote{modTime, size}
+	}
+
+	for _, line := range lines[2+n:] {
+		word, postings, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, errors.New("corrupt search index")
+		}
+		if wanted != nil && !wanted(word) {
+			continue
+		}
+		for _, field := range strings.Fields(postings) {
+			i, err := strconv.Atoi(field)
+			if err != nil || i < 0 || i >= n {
+				return nil, errors.New("corrupt search index")
+			}
+			idx.tokens[word] = append(idx.tokens[word], ids[i])
+		}
+	}
+	return idx, nil
+}
+
+func (idx *searchIndex) save(zettelHome string) error {
+	ids := make([]string, 0, len(idx.notes))
+	for id := range idx.notes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	position := make(map[string]int, len(ids))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%d\n", searchIndexHeader, len(ids))
+	for i, id := range ids {
+		position[id] = i
+		fmt.Fprintf(&b, "%s\t%d\t%d\n", id, idx.notes[id].modTime, idx.notes[id].size)
+	}
+
+	words := make([]string, 0, len(idx.tokens))
+	for word := range idx.tokens {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	for _, word := range words {
+		b.WriteString(word)
+		for i, id := range idx.tokens[word] {
+			if i == 0 {
+				b.WriteByte('\t')
+			} else {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strconv.Itoa(position[id]))
+		}
+		b.WriteByte('\n')
+	}
+
+	return writeFileAtomic(filepath.Join(zettelHome, searchIndexFile), []byte(b.String()))
+}
+
+// noteStates stats every note in zettelHome.
+func noteStates(zettelHome string) (map[string]indexedNote, error) {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]indexedNote, len(ids))
+	for _, id := range ids {
+		info, err := os.Stat(notePath(zettelHome, id))
+		if err != nil {
+			return nil, err
+		}
+		states[id] = indexedNote{info.ModTime().UnixNano(), info.Size()}
+	}
+	return states, nil
+}
+
+// remove drops id from every posting list.
+func (idx *searchIndex) remove(id string) {
+	for token, ids := range idx.tokens {
+		if i := sort.SearchStrings(ids, id); i < len(ids) && ids[i] == id {
+			if len(ids) == 1 {
+				delete(idx.tokens, token)
+			} else {
+				idx.tokens[token] = append(ids[:i], ids[i+1:]...)
+			}
+		}
+	}
+	delete(idx.notes, id)
+}
+
+// add indexes the words of content under id.
+func (idx *searchIndex) add(id, content string, state indexedNote) {
+	seen := map[string]bool{}
+	for _, token := range tokenize(content) {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		ids := idx.tokens[token]
+		i := sort.SearchStrings(ids, id)
+		idx.tokens[token] = append(ids[:i], append([]string{id}, ids[i:]...)...)
+	}
+	idx.notes[id] = state
+}
+
+// refresh re-indexes the notes that changed since they were indexed and
+// drops the deleted ones, reporting whether anything changed.
+func (idx *searchIndex) refresh(zettelHome string, states map[string]indexedNote) (bool, error) {
+	changed := false
+	for id := range idx.notes {
+		if _, ok := states[id]; !ok {
+			idx.remove(id)
+			changed = true
+		}
+	}
+	// Visiting the notes in order keeps add appending to the posting lists
+	// rather than inserting into them.
+	ids := make([]string, 0, len(states))
+	for id := range states {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		old, indexed := idx.notes[id]
+		if indexed && old == states[id] {
+			continue
+		}
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return changed, err
+		}
+		if indexed {
+			idx.remove(id)
+		}
+		idx.add(id, content, states[id])
+		changed = true
+	}
+	return changed, nil
+}
+
+// buildSearchIndex indexes every note from scratch.
+func buildSearchIndex(zettelHome string) (*searchIndex, error) {
+	states, err := noteStates(zettelHome)
+	if err != nil {
+		return nil, err
+	}
+	idx := &searchIndex{notes: map[string]indexedNote{}, tokens: map[string][]string{}}
+	if _, err := idx.refresh(zettelHome, states); err != nil {
+		return nil, err
+	}
+	return idx, idx.save(zettelHome)
+}
+
+// updateSearchIndex brings an existing search index up to date after notes
+// changed. Vaults without an index are left without one.
+func updateSearchIndex(zettelHome string) {
+	idx, err := readSearchIndex(zettelHome, nil)
+	if err != nil {
+		return
+	}
+	states, err := noteStates(zettelHome)
+	if err != nil {
+		return
+	}
+	if changed, err := idx.refresh(zettelHome, states); err != nil || !changed {
+		return
+	}
+	if err := idx.save(zettelHome); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: updating search index:", err)
+	}
+}
+
+// indexedCandidates returns the notes that may contain query, using the
+// search index: those with, for every word of query, a word containing it.
+// ok is false when there is no usable index, it is stale, or query has no
+// words to look up, and the caller should scan every note instead.
+func indexedCandidates(zettelHome, query string) (ids []string, ok bool) {
+	words := tokenize(query)
+	if len(words) == 0 {
+		return nil, false
+	}
+	idx, err := readSearchIndex(zettelHome, func(word string) bool {
+		for _, w := range words {
+			if strings.Contains(word, w) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, false
+	}
+	states, err := noteStates(zettelHome)
+	if err != nil || len(states) != len(idx.notes) {
+		return nil, false
+	}
+	for id, state := range states {
+		if idx.notes[id] != state {
+			return nil, false
+		}
+	}
+
+	var candidates map[string]bool
+	for _, word := range words {
+		matching := map[string]bool{}
+		for token, tokenIDs := range idx.tokens {
+			if !strings.Contains(token, word) {
+				continue
+			}
+			for _, id := range tokenIDs {
+				if candidates == nil || candidates[id] {
+					matching[id] = true
+				}
+			}
+		}
+		candidates = matching
+	}
+
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, true
+}
+
+func rebuildSearchIndex(zettelHome string) error {
+	idx, err := buildSearchIndex(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error building search index: %w", err)
+	}
+	fmt.Printf("Indexed %d notes, %d words\n", len(idx.notes), len(idx.tokens))
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"#project/zettel [[20240101-idea]]", []string{"project", "zettel", "20240101", "idea"}},
+		{"Café über naïve", []string{"café", "über", "naïve"}},
+		{" -- ", []string{}},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"a":               "# Alpha\n\nThe zettelkasten method.\n",
+		"b":               "# Beta\n\nA note about methods and tags.\n",
+		"c":               "# Gamma\n\nNothing here.\n",
+		"archive/old":     "# Old\n\nzettelkasten history\n",
+		".templates/meet": "# Meeting\n\nzettelkasten agenda\n",
+	})
+
+	if _, ok := indexedCandidates(home, "method"); ok {
+		t.Fatal("indexedCandidates used a missing index")
+	}
+	if _, err := buildSearchIndex(home); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := readSearchIndex(home, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.notes) != 3 {
+		t.Errorf("indexed %d notes, want the 3 outside archive and .templates", len(idx.notes))
+	}
+	if got := idx.tokens["the"]; !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf(`postings of "the" = %q`, got)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"method", []string{"a", "b"}},
+		{"Zettel", []string{"a"}},
+		{"the method", []string{"a"}},
+		{"about tags", []string{"b"}},
+		{"absent", nil},
+	}
+	for _, tt := range tests {
+		got, ok := indexedCandidates(home, tt.query)
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("indexedCandidates(%q) = %q, %v; want %q", tt.query, got, ok, tt.want)
+		}
+	}
+	if _, ok := indexedCandidates(home, "--"); ok {
+		t.Error("indexedCandidates used the index for a query without words")
+	}
+
+	if err := os.WriteFile(notePath(home, "c"), []byte("# Gamma\n\nNow about the method.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := indexedCandidates(home, "method"); ok {
+		t.Error("indexedCandidates used a stale index after a note changed")
+	}
+	updateSearchIndex(home)
+	if got, ok := indexedCandidates(home, "method"); !ok || !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("after updateSearchIndex, indexedCandidates = %q, %v", got, ok)
+	}
+
+	if err := os.Remove(notePath(home, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := indexedCandidates(home, "method"); ok {
+		t.Error("indexedCandidates used a stale index after a note was deleted")
+	}
+	updateSearchIndex(home)
+	if got, ok := indexedCandidates(home, "zettelkasten"); !ok || got != nil {
+		t.Errorf("after deleting a, indexedCandidates = %q, %v", got, ok)
+	}
+}
+
+func TestSearchIndexCorrupt(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"zettel-index 0\n0\n",
+		searchIndexHeader + "\n2\na\t1\t1\n",
+		searchIndexHeader + "\n1\na\t1\t1\nword\t1\n",
+		searchIndexHeader + "\n1\na\tx\t1\n",
+	} {
+		home := t.TempDir()
+		if err := os.WriteFile(filepath.Join(home, searchIndexFile), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readSearchIndex(home, nil); err == nil {
+			t.Errorf("readSearchIndex accepted %q", content)
+		}
+	}
+}
+
+// TestSearchIndexSpeed times search on a synthetic vault with and without
+// the index. It only logs the speedup, which depends on the machine, but
+// checks that both find the same notes.
+func TestSearchIndexSpeed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building a large vault")
+	}
+	words := strings.Fields("alpha beta gamma delta epsilon zeta theta iota kappa lambda mu nu xi omicron pi rho sigma tau")
+	notes := map[string]string{}
+	for i := 0; i < 5000; i++ {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# Note %d\n\n", i)
+		for j := 0; j < 200; j++ {
+			b.WriteString(words[(i*7+j*j)%len(words)] + " ")
+		}
+		if i%500 == 0 {
+			b.WriteString("needle\n")
+		}
+		notes[fmt.Sprintf("20240101%06d", i)] = b.String()
+	}
+	home := testVault(t, notes)
+
+	search := func() (string, time.Duration) {
+		start := time.Now()
+		out, code := runZettel(t, home, "search", "--files-only", "needle")
+		if code != 0 {
+			t.Fatalf("search exited %d", code)
+		}
+		return out, time.Since(start)
+	}
+	scanned, scanTime := search()
+	if _, err := buildSearchIndex(home); err != nil {
+		t.Fatal(err)
+	}
+	indexed, indexTime := search()
+
+	if scanned != indexed || strings.Count(indexed, "\n") != 10 {
+		t.Errorf("search found\n%s\nwithout the index and\n%s\nwith it", scanned, indexed)
+	}
+	t.Logf("searching %d notes: %v scanning, %v with the index (%.1fx)", len(notes), scanTime, indexTime, float64(scanTime)/float64(indexTime))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// runShell reads commands from stdin and runs each as its own zettel
+// invocation on zettelHome, so that the shell dispatches exactly like the
+// command line and a failing command does not end the session. "history"
+// lists the commands entered so far, "!!" and "!N" rerun one of them, and
+// "exit" or "quit" leave the shell.
+func runShell(zettelHome string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+
+	// Ctrl-C interrupts the running command, which receives it too, but
+	// not the shell itself.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		for range interrupt {
+		}
+	}()
+
+	fmt.Printf("zettel shell on %s; type \"exit\" to leave\n", zettelHome)
+	var history []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("zettel> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return nil
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case line == "exit" || line == "quit":
+			return nil
+		case line == "history":
+			for i, entry := range history {
+				fmt.Printf("%4d  %s\n", i+1, entry)
+			}
+			continue
+		case strings.HasPrefix(line, "!"):
+			n, err := len(history), error(nil)
+			if line != "!!" {
+				n, err = strconv.Atoi(line[1:])
+			}
+			if err != nil || n < 1 || n > len(history) {
+				fmt.Println("No such command in history:", line)
+				continue
+			}
+			line = history[n-1]
+			fmt.Println(line)
+		}
+		history = append(history, line)
+
+		args, err := splitCommand(line)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		if args[0] == "zettel" {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "shell" {
+			fmt.Println("Already in the shell")
+			continue
+		}
+		if !strings.HasPrefix(args[0], "-") && !slices.Contains(subcommands, args[0]) {
+			fmt.Println("Unknown command:", args[0])
+			continue
+		}
+
+		cmd := exec.Command(exe, append([]string{"--dir", zettelHome}, args...)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		var exitErr *exec.ExitError
+		if err := cmd.Run(); err != nil && !errors.As(err, &exitErr) {
+			fmt.Println("Error:", err)
+		}
+	}
+}
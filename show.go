@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// styleNote lightly styles markdown for the terminal: headings in bold,
+// wikilinks underlined and #tags colored, leaving code blocks alone.
+func styleNote(content string) string {
+	if !useColor() {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		if _, ok := parseHeading(line); ok {
+			lines[i] = colorTitle + line + colorReset
+			continue
+		}
+		line = wikiLinkRegex.ReplaceAllString(line, colorLink+"$0"+colorReset)
+		lines[i] = validTagRegex.ReplaceAllStringFunc(line, func(match string) string {
+			tag := strings.TrimLeft(match, " \t")
+			return match[:len(match)-len(tag)] + colorTag + tag + colorReset
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showNote prints a note, styled unless raw is set, through $PAGER when
+// that is set and stdout is a terminal.
+func showNote(zettelHome, id string, raw bool) error {
+	content, err := readNote(zettelHome, id)
+	if os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+	if !raw {
+		content = styleNote(content)
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" || !stdoutIsTerminal() {
+		fmt.Print(content)
+		return nil
+	}
+	args, err := splitCommand(pager)
+	if err != nil || len(args) == 0 {
+		return fmt.Errorf("Error: cannot run pager %q", pager)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Let less pass the styling through and quit on short notes.
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Error running pager: %w", err)
+	}
+	return nil
+}
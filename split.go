@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// findSection locates the heading named by name among lines, either written
+// out with its level as in "## Foo" or as bare text matching a heading of
+// any level, ignoring case. It returns the line of the heading and the line
+// where its section ends: the next heading of the same or a higher level,
+// or the end of lines.
+func findSection(lines []string, name string) (h heading, start, end int, ok bool) {
+	want, leveled := parseHeading(strings.TrimSpace(name))
+	if !leveled {
+		want.text = strings.TrimSpace(name)
+	}
+
+	start = -1
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		current, isHeading := parseHeading(line)
+		if !isHeading {
+			continue
+		}
+		if start >= 0 && current.level <= h.level {
+			return h, start, i, true
+		}
+		if start < 0 && strings.EqualFold(current.text, want.text) && (!leveled || current.level == want.level) {
+			h, start = current, i
+		}
+	}
+	return h, start, len(lines), start >= 0
+}
+
+// extractInEditor opens body in the editor for the user to delete all but
+// the text to split off, and returns that text.
+func extractInEditor(body string) (string, error) {
+	f, err := os.CreateTemp("", "zettel-split-*"+noteExtension)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := openEditor(f.Name()); err != nil {
+		return "", err
+	}
+
+	extracted, err := os.ReadFile(f.Name())
+	return strings.Trim(string(extracted), "\n"), err
+}
+
+// splitNote moves a section of a note into a new note and leaves a link to
+// it in its place. The section is the one under fromHeading, or else the
+// text the user keeps when editing a copy of the note. The new note is
+// titled after the section's heading and gets the note's tags.
+func splitNote(zettelHome, id, fromHeading string) error {
+	content, err := readNote(zettelHome, id)
+	if os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+	block, body, hasFrontmatter := splitFrontmatter(content)
+
+	var title, section string
+	var replace func(link string) string
+	if fromHeading != "" {
+		lines := strings.Split(body, "\n")
+		h, start, end, ok := findSection(lines, fromHeading)
+		if !ok {
+			return notFoundf("Heading not found in %s: %s", id, fromHeading)
+		}
+		title, section = h.text, strings.Join(lines[start+1:end], "\n")
+		replace = func(link string) string {
+			kept := append([]string{}, lines[:start]...)
+			kept = append(kept, link)
+			if end < len(lines) {
+				kept = append(kept, "")
+			}
+			return strings.Join(append(kept, lines[end:]...), "\n")
+		}
+	} else {
+		extracted, err := extractInEditor(body)
+		if err != nil {
+			return fmt.Errorf("Error opening editor: %w", err)
+		}
+		if strings.TrimSpace(extracted) == "" {
+			fmt.Println("Nothing to split off")
+			return nil
+		}
+		pos := strings.Index(body, extracted)
+		if pos < 0 {
+			return errors.New("Error: the text to split off must be left unchanged in the editor")
+		}
+		section = extracted
+		first, rest, _ := strings.Cut(extracted, "\n")
+		if h, ok := parseHeading(first); ok {
+			title, section = h.text, rest
+		}
+		replace = func(link string) string {
+			return body[:pos] + link + body[pos+len(extracted):]
+		}
+	}
+
+	newID, f, err := createNoteFile(zettelHome, newNoteID(title, false))
+	if err != nil {
+		return fmt.Errorf("Error creating note: %w", err)
+	}
+	if title == "" {
+		title = newID
+	}
+
+	_, err = f.WriteString(splitNoteContent(content, title, section))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		updated := replace("[[" + newID + "]]")
+		if hasFrontmatter {
+			updated = "---\n" + block + "\n---\n" + updated
+		}
+		err = writeFileAtomic(notePath(zettelHome, id), []byte(updated))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("Error splitting note: %w", err)
+	}
+
+	recordChange(zettelHome, "split "+id+" -> "+newID)
+	fmt.Println(newID + noteExtension)
+	return nil
+}
+
+// splitNoteContent builds the note split off content: title as its heading,
+// the section, and the tags of content the section does not already have,
+// or the placeholder tag if there are none.
+func splitNoteContent(content, title, section string) string {
+	var tags []string
+	have := noteTags(section)
+	for _, tag := range noteTags(content) {
+		if tag != placeholderTag() && !hasTags(have, []string{tag}, false) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 && len(have) == 0 {
+		tags = []string{placeholderTag()}
+	}
+
+	text := "# " + title + "\n\n" + strings.Trim(section, "\n") + "\n"
+	if len(tags) > 0 {
+		text += "\n#" + strings.Join(tags, " #") + "\n"
+	}
+	return text
+}
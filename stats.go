@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+)
+
+type vaultStats struct {
+	notes, words, tags, links, orphans int
+}
+
+// collectStats gathers the vault statistics reading every note once. Links
+// count as connecting notes when they resolve the way backlinks and orphans
+// resolve them, by filename, alias, slug or title.
+func collectStats(zettelHome string) (vaultStats, error) {
+	var stats vaultStats
+	r, err := newLinkResolver(zettelHome)
+	if err != nil {
+		return stats, err
+	}
+	ids := sortedKeys(r.contents)
+
+	tags := map[string]bool{}
+	linked := map[string]bool{}
+	index := map[string]bool{}
+	for _, id := range ids {
+		content := r.contents[id]
+		_, body := parseFrontmatter(content)
+		stats.words += countWords(body)
+		for _, tag := range noteTags(content) {
+			tags[tag] = true
+		}
+		for _, target := range parseLinks(content) {
+			stats.links++
+			if resolved, _ := r.resolve(target); r.ids[resolved] {
+				linked[id] = true
+				linked[resolved] = true
+			}
+		}
+		index[id] = isIndexNote(content)
+	}
+
+	stats.notes = len(ids)
+	stats.tags = len(tags)
+	for _, id := range ids {
+		if !linked[id] && !index[id] {
+			stats.orphans++
+		}
+	}
+
+	return stats, nil
+}
+
+func printStats(zettelHome string) error {
+	stats, err := collectStats(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	fmt.Printf("%-8s %8d\n", "Notes", stats.notes)
+	fmt.Printf("%-8s %8d\n", "Words", stats.words)
+	fmt.Printf("%-8s %8d\n", "Tags", stats.tags)
+	fmt.Printf("%-8s %8d\n", "Links", stats.links)
+	fmt.Printf("%-8s %8d\n", "Orphans", stats.orphans)
+	return nil
+}
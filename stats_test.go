@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestCollectStats(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"20240101120000-alpha":      "# Alpha\n\nSee [[Beta Notes]] #one\n",
+		"20240102120000-beta-notes": "# Beta Notes\n\n#one #two\n",
+		"20240103120000-gamma":      "# Gamma\n\n[[missing]]\n",
+		"20240104120000-index":      "# Index\n\n" + indexStartMarker + " -->\n",
+	})
+	stats, err := collectStats(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := vaultStats{notes: 4, words: 19, tags: 2, links: 2, orphans: 1}
+	if stats != want {
+		t.Errorf("collectStats = %+v, want %+v", stats, want)
+	}
+
+	orphans, err := orphanNotes(home, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != stats.orphans {
+		t.Errorf("stats counts %d orphans, orphans lists %q", stats.orphans, orphans)
+	}
+}
@@ -0,0 +1,569 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+const defaultPlaceholderTag = "tagme"
+
+// validTagRegex matches a whole-word #tag, which may be nested as in
+// #project/zettel; the tag name is captured without the leading '#'.
+var validTagRegex = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+(?:/[\p{L}\p{N}_-]+)*)`)
+
+// placeholderTag is the tag seeded into new notes as a reminder to tag them,
+// configurable through ZETTEL_PLACEHOLDER_TAG or the config file.
+func placeholderTag() string {
+	if tag := os.Getenv("ZETTEL_PLACEHOLDER_TAG"); tag != "" {
+		return strings.TrimPrefix(tag, "#")
+	}
+	return cfg.PlaceholderTag
+}
+
+// noteTags returns the unique tags in content, both inline #tags and those
+// listed in a frontmatter "tags:" field, sorted.
+func noteTags(content string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, tag := range frontmatterList(content, "tags") {
+		add(strings.TrimPrefix(tag, "#"))
+	}
+	for _, match := range validTagRegex.FindAllStringSubmatch(content, -1) {
+		add(match[1])
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// hasTags reports whether tags contains all of want, or any of them when
+// anyTag is set. A nested tag such as project/zettel also counts as its
+// parents. An empty want always matches.
+func hasTags(tags, want []string, anyTag bool) bool {
+	if len(want) == 0 {
+		return true
+	}
+
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		for i, c := range tag {
+			if c == '/' {
+				set[tag[:i]] = true
+			}
+		}
+		set[tag] = true
+	}
+	for _, tag := range want {
+		tag = strings.TrimPrefix(tag, "#")
+		if set[tag] && anyTag {
+			return true
+		}
+		if !set[tag] && !anyTag {
+			return false
+		}
+	}
+	return !anyTag
+}
+
+// removeTag strips every whole-word #tag from content, along with a comma
+// after it and the whitespace separating it from the text before, or after
+// when it starts the line. Lines left empty are dropped, and tag is removed
+// from the frontmatter tag list.
+// It returns the new content and the number of occurrences removed.
+func removeTag(content, tag string) (string, int) {
+	removed := 0
+	if listed := frontmatterList(content, "tags"); len(listed) > 0 {
+		remaining := listed[:0]
+		for _, t := range listed {
+			if strings.TrimPrefix(t, "#") == tag {
+				removed++
+				continue
+			}
+			remaining = append(remaining, t)
+		}
+		if removed > 0 {
+			content = setFrontmatterList(content, "tags", remaining)
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		var b strings.Builder
+		last, n := 0, 0
+		for _, m := range validTagRegex.FindAllStringSubmatchIndex(line, -1) {
+			if line[m[2]:m[3]] != tag {
+				continue
+			}
+			start, end := m[2]-1, m[3]
+			if end < len(line) && line[end] == ',' {
+				end++
+			}
+			if strings.TrimSpace(b.String()+line[last:start]) == "" {
+				for end < len(line) && (line[end] == ' ' || line[end] == '\t') {
+					end++
+				}
+			} else {
+				for start > last && (line[start-1] == ' ' || line[start-1] == '\t') {
+					start--
+				}
+			}
+			b.WriteString(line[last:start])
+			last = end
+			n++
+		}
+		if n == 0 {
+			kept = append(kept, line)
+			continue
+		}
+		removed += n
+		b.WriteString(line[last:])
+		if strings.TrimSpace(b.String()) != "" {
+			kept = append(kept, b.String())
+		}
+	}
+
+	return strings.Join(kept, "\n"), removed
+}
+
+// isTagName reports whether tag, without its '#', is a whole valid tag.
+func isTagName(tag string) bool {
+	m := validTagRegex.FindStringSubmatch("#" + tag)
+	return m != nil && m[1] == tag
+}
+
+// isTagLine reports whether line consists only of #tags.
+func isTagLine(line string) bool {
+	fields := strings.Fields(line)
+	for _, field := range fields {
+		if !strings.HasPrefix(field, "#") || !isTagName(field[1:]) {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// addTag adds #tag to content: to the frontmatter tag list if there is one,
+// else to the end of the last line holding only tags, else on a line of its
+// own at the end.
+func addTag(content, tag string) string {
+	if listed := frontmatterList(content, "tags"); len(listed) > 0 {
+		return setFrontmatterList(content, "tags", append(listed, tag))
+	}
+
+	lines := strings.Split(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if isTagLine(lines[i]) {
+			lines[i] = strings.TrimRight(lines[i], " \t") + " #" + tag
+			return strings.Join(lines, "\n")
+		}
+	}
+	return strings.TrimRight(content, "\n") + "\n\n#" + tag + "\n"
+}
+
+// tagNote adds tag to the note id, or removes every occurrence of it when
+// remove is set. Adding a tag the note already has changes nothing, while
+// removing one it does not have exits with exitNotFound.
+func tagNote(zettelHome, id, tag string, remove bool) error {
+	tag = strings.TrimPrefix(tag, "#")
+	if !isTagName(tag) {
+		return usagef("Invalid tag: %v", tag)
+	}
+	content, err := readNote(zettelHome, id)
+	if os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", id)
+	} else if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+
+	updated, change := addTag(content, tag), "tag "+id+" #"+tag
+	if remove {
+		var removed int
+		updated, removed = removeTag(content, tag)
+		if removed == 0 {
+			return notFoundf("Tag #%s not found in %s", tag, id)
+		}
+		change = "untag " + id + " #" + tag
+	} else if slices.Contains(noteTags(content), tag) {
+		fmt.Printf("%s is already tagged #%s\n", id, tag)
+		return nil
+	}
+	if err := stageWrite(zettelHome, notePath(zettelHome, id), []byte(updated), change); err != nil {
+		return fmt.Errorf("Error writing note: %w", err)
+	}
+	if dryRun {
+		return nil
+	}
+	recordChange(zettelHome, change)
+	if remove {
+		fmt.Printf("Removed #%s from %s\n", tag, id)
+	} else {
+		fmt.Printf("Tagged %s #%s\n", id, tag)
+	}
+	return nil
+}
+
+// renameTag replaces every whole-word #oldTag in content, including entries
+// of the frontmatter tag list, with #newTag. It returns the new content and
+// the number of occurrences replaced.
+func renameTag(content, oldTag, newTag string) (string, int) {
+	renamed := 0
+	if listed := frontmatterList(content, "tags"); len(listed) > 0 {
+		for i, tag := range listed {
+			if strings.TrimPrefix(tag, "#") == oldTag {
+				listed[i] = newTag
+				renamed++
+			}
+		}
+		if renamed > 0 {
+			content = setFrontmatterList(content, "tags", listed)
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range validTagRegex.FindAllStringSubmatchIndex(content, -1) {
+		if content[m[2]:m[3]] != oldTag {
+			continue
+		}
+		b.WriteString(content[last:m[2]])
+		b.WriteString(newTag)
+		last = m[3]
+		renamed++
+	}
+	b.WriteString(content[last:])
+
+	return b.String(), renamed
+}
+
+// renameTagInNotes renames a tag across the vault, or only in the note
+// named by note if it is set, only reporting what would change under
+// --dry-run.
+func renameTagInNotes(zettelHome, oldTag, newTag, note string) error {
+	oldTag = strings.TrimPrefix(oldTag, "#")
+	newTag = strings.TrimPrefix(newTag, "#")
+	if !isTagName(newTag) {
+		return usagef("Invalid tag: %v", newTag)
+	}
+
+	ids := []string{note}
+	if note == "" {
+		var err error
+		if ids, err = listNoteIDs(zettelHome); err != nil {
+			return fmt.Errorf("Error listing notes: %w", err)
+		}
+	} else if _, err := os.Stat(notePath(zettelHome, note)); os.IsNotExist(err) {
+		return notFoundf("Note does not exist: %v", note)
+	}
+
+	occurrences, notes := 0, 0
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+
+		updated, n := renameTag(content, oldTag, newTag)
+		if n == 0 {
+			continue
+		}
+		occurrences += n
+		notes++
+
+		if err := stageWrite(zettelHome, notePath(zettelHome, id), []byte(updated), fmt.Sprintf("%d occurrences", n)); err != nil {
+			return fmt.Errorf("Error writing note: %w", err)
+		}
+	}
+
+	if notes == 0 && note != "" {
+		return notFoundf("%s is not tagged #%s", note, oldTag)
+	} else if notes == 0 {
+		return notFoundf("No notes tagged #%s", oldTag)
+	}
+
+	verb := "Renamed"
+	if dryRun {
+		verb = "Would rename"
+	} else if note != "" {
+		recordChange(zettelHome, "rename tag #"+oldTag+" to #"+newTag+" in "+note)
+	} else {
+		recordChange(zettelHome, "rename tag #"+oldTag+" to #"+newTag)
+	}
+	if note != "" {
+		fmt.Printf("%s #%s to #%s in %s: %d occurrences\n", verb, oldTag, newTag, note, occurrences)
+		return nil
+	}
+	fmt.Printf("%s #%s to #%s: %d occurrences in %d notes\n", verb, oldTag, newTag, occurrences, notes)
+	return nil
+}
+
+// collectTags returns the unique tags of the notes ids, sorted, and how many
+// notes use each.
+func collectTags(zettelHome string, ids []string) ([]string, map[string]int, error) {
+	counts := map[string]int{}
+	var tags []string
+	for _, id := range ids {
+		content, err := os.ReadFile(filepath.Join(zettelHome, id+noteExtension))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, tag := range noteTags(string(content)) {
+			if counts[tag] == 0 {
+				tags = append(tags, tag)
+			}
+			counts[tag]++
+		}
+	}
+	sort.Strings(tags)
+	return tags, counts, nil
+}
+
+// tagListOptions selects and formats the tags printed by listTags.
+type tagListOptions struct {
+	hidePlaceholder bool
+	// count prints the number of notes using each tag, most used first.
+	count bool
+	// tree prints nested tags indented under their parents.
+	tree bool
+	// byNote prints every note with its own tags instead of the unique set.
+	byNote bool
+	// stats prints the number of notes, words and average words of each
+	// tag, most used first.
+	stats bool
+}
+
+func listTags(zettelHome string, opts tagListOptions) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	if opts.byNote {
+		return listTagsByNote(zettelHome, ids, opts.hidePlaceholder)
+	}
+	if opts.stats {
+		return listTagStats(zettelHome, ids, opts.hidePlaceholder)
+	}
+
+	tags, counts, err := collectTags(zettelHome, ids)
+	if err != nil {
+		return fmt.Errorf("Error reading note: %w", err)
+	}
+	if opts.tree && !jsonOutput {
+		printTagTree(tags, counts, opts)
+		return nil
+	}
+	if opts.count {
+		sort.SliceStable(tags, func(i, j int) bool { return counts[tags[i]] > counts[tags[j]] })
+	}
+
+	type tagResult struct {
+		Tag   string `json:"tag"`
+		Count int    `json:"count,omitempty"`
+	}
+	results := []tagResult{}
+	placeholder := placeholderTag()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, tag := range tags {
+		if opts.hidePlaceholder && tag == placeholder {
+			continue
+		}
+		switch {
+		case jsonOutput && opts.count:
+			results = append(results, tagResult{tag, counts[tag]})
+		case jsonOutput:
+			results = append(results, tagResult{Tag: tag})
+		case opts.count:
+			fmt.Fprintf(w, "%s\t%d\n", colorize(colorTag, "#"+tag), counts[tag])
+		default:
+			fmt.Println(colorize(colorTag, "#"+tag))
+		}
+	}
+	w.Flush()
+
+	if jsonOutput {
+		return printJSON(results)
+	}
+	return nil
+}
+
+// listTagStats prints how many notes use each tag, how many words those
+// notes have together and how many each has on average, reading every note
+// once. Words are counted as stats does, without the frontmatter.
+func listTagStats(zettelHome string, ids []string, hidePlaceholder bool) error {
+	notes := map[string]int{}
+	words := map[string]int{}
+	placeholder := placeholderTag()
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+		_, body := parseFrontmatter(content)
+		n := countWords(body)
+		for _, tag := range noteTags(content) {
+			if hidePlaceholder && tag == placeholder {
+				continue
+			}
+			notes[tag]++
+			words[tag] += n
+		}
+	}
+
+	tags := sortedKeys(notes)
+	sort.SliceStable(tags, func(i, j int) bool { return notes[tags[i]] > notes[tags[j]] })
+
+	if jsonOutput {
+		type tagStats struct {
+			Tag          string  `json:"tag"`
+			Notes        int     `json:"notes"`
+			Words        int     `json:"words"`
+			AverageWords float64 `json:"average_words"`
+		}
+		results := []tagStats{}
+		for _, tag := range tags {
+			results = append(results, tagStats{tag, notes[tag], words[tag], float64(words[tag]) / float64(notes[tag])})
+		}
+		return printJSON(results)
+	}
+
+	width := len("Tag")
+	for _, tag := range tags {
+		width = max(width, utf8.RuneCountInString(tag)+1)
+	}
+	fmt.Printf("%-*s %8s %8s %8s\n", width, "Tag", "Notes", "Words", "Average")
+	for _, tag := range tags {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(tag)-1)
+		average := (words[tag] + notes[tag]/2) / notes[tag]
+		fmt.Printf("%s%s %8d %8d %8d\n", colorize(colorTag, "#"+tag), pad, notes[tag], words[tag], average)
+	}
+	return nil
+}
+
+// listTagsByNote prints each note followed by its tags, including the notes
+// without any so that untagged notes stand out.
+func listTagsByNote(zettelHome string, ids []string, hidePlaceholder bool) error {
+	type noteTagsResult struct {
+		Filename string   `json:"filename"`
+		Tags     []string `json:"tags"`
+	}
+	results := []noteTagsResult{}
+	placeholder := placeholderTag()
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+
+		tags := []string{}
+		for _, tag := range noteTags(content) {
+			if !hidePlaceholder || tag != placeholder {
+				tags = append(tags, tag)
+			}
+		}
+		if jsonOutput {
+			results = append(results, noteTagsResult{id + noteExtension, tags})
+			continue
+		}
+		line := colorize(colorFile, id+noteExtension) + ":"
+		for _, tag := range tags {
+			line += " " + colorize(colorTag, "#"+tag)
+		}
+		fmt.Println(line)
+	}
+
+	if jsonOutput {
+		return printJSON(results)
+	}
+	return nil
+}
+
+// printTagTree prints sorted tags as a hierarchy, each level indented under
+// its parent by its last path segment. Parents that are not used as tags
+// themselves are still printed to group their children.
+func printTagTree(tags []string, counts map[string]int, opts tagListOptions) {
+	placeholder := placeholderTag()
+	printed := map[string]bool{}
+	for _, tag := range tags {
+		if opts.hidePlaceholder && tag == placeholder {
+			continue
+		}
+
+		segments := strings.Split(tag, "/")
+		for depth := range segments {
+			name := strings.Join(segments[:depth+1], "/")
+			if printed[name] {
+				continue
+			}
+			printed[name] = true
+
+			line := strings.Repeat("  ", depth) + colorize(colorTag, "#"+segments[depth])
+			if opts.count && counts[name] > 0 {
+				line += fmt.Sprintf(" (%d)", counts[name])
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// trimPlaceholderTag reports the notes still only carrying the placeholder
+// tag and those that have since been given a real tag, removing it from the
+// latter after confirmation when trim is set.
+func trimPlaceholderTag(zettelHome string, trim bool) error {
+	ids, err := listNoteIDs(zettelHome)
+	if err != nil {
+		return fmt.Errorf("Error listing notes: %w", err)
+	}
+
+	placeholder := placeholderTag()
+	updates := map[string]string{}
+	for _, id := range ids {
+		content, err := readNote(zettelHome, id)
+		if err != nil {
+			return fmt.Errorf("Error reading note: %w", err)
+		}
+
+		tags := noteTags(content)
+		if !slices.Contains(tags, placeholder) {
+			continue
+		}
+		if len(tags) == 1 {
+			fmt.Printf("%s: still tagged #%s\n", id, placeholder)
+			continue
+		}
+		fmt.Printf("%s: #%s can be removed\n", id, placeholder)
+		updates[id], _ = removeTag(content, placeholder)
+	}
+
+	if !trim || len(updates) == 0 {
+		return nil
+	}
+	if !dryRun && !confirm(fmt.Sprintf("Remove #%s from %d notes?", placeholder, len(updates))) {
+		fmt.Println("Aborted")
+		return nil
+	}
+	for _, id := range sortedKeys(updates) {
+		if err := stageWrite(zettelHome, notePath(zettelHome, id), []byte(updates[id]), "remove #"+placeholder); err != nil {
+			return fmt.Errorf("Error writing note: %w", err)
+		}
+	}
+	if dryRun {
+		return nil
+	}
+	recordChange(zettelHome, fmt.Sprintf("remove #%s from %d notes", placeholder, len(updates)))
+	fmt.Printf("Removed #%s from %d notes\n", placeholder, len(updates))
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemoveTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		removed int
+	}{
+		{"own line", "# A\n\n#foo\n", "# A\n\n", 1},
+		{"line start", "#foo #bar\n", "#bar\n", 1},
+		{"between words", "some #foo text\n", "some text\n", 1},
+		{"comma separated", "#bar, #foo,\n", "#bar,\n", 1},
+		{"keeps spacing", "#bar   #foo\t#baz\n", "#bar\t#baz\n", 1},
+		{"repeated", "#foo #foo x #foo\n", "x\n", 3},
+		{"longer tags kept", "#foobar #foo/sub #food\n", "#foobar #foo/sub #food\n", 0},
+		{"not a tag", "a#foo and [[#foo]]\n", "a#foo and [[#foo]]\n", 0},
+		{"frontmatter", "---\ntags: [foo, bar]\n---\n#foo\n", "---\ntags: [bar]\n---\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := removeTag(tt.content, "foo")
+			if got != tt.want || removed != tt.removed {
+				t.Errorf("removeTag(%q) = %q, %d; want %q, %d", tt.content, got, removed, tt.want, tt.removed)
+			}
+		})
+	}
+}
+
+func TestTagsNoPlaceholder(t *testing.T) {
+	home := testVault(t, map[string]string{
+		"a": "# A\n\n#tagme\n",
+		"b": "# B\n\n#tagme #real\n",
+	})
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"tags"}, "#real\n#tagme\n"},
+		{[]string{"tags", "--no-placeholder"}, "#real\n"},
+	}
+	for _, tt := range tests {
+		if out, _ := runZettel(t, home, tt.args...); out != tt.want {
+			t.Errorf("%v printed %q, want %q", tt.args, out, tt.want)
+		}
+	}
+}
+
+func TestTrimPlaceholderTag(t *testing.T) {
+	notes := map[string]string{
+		"only":  "# Only\n\n#tagme\n",
+		"mixed": "# Mixed\n\n#tagme #real\n",
+	}
+
+	home := testVault(t, notes)
+	out, _ := runZettel(t, home, "doctor", "--trim-tagme")
+	if !strings.Contains(out, "only: still tagged #tagme") || !strings.Contains(out, "mixed: #tagme can be removed") {
+		t.Errorf("doctor --trim-tagme printed %q", out)
+	}
+	if got := readTestNote(t, home, "mixed"); got != notes["mixed"] {
+		t.Errorf("without --yes mixed = %q, want it unchanged", got)
+	}
+
+	runZettel(t, home, "--dry-run", "doctor", "--trim-tagme")
+	if got := readTestNote(t, home, "mixed"); got != notes["mixed"] {
+		t.Errorf("under --dry-run mixed = %q, want it unchanged", got)
+	}
+
+	runZettel(t, home, "--yes", "doctor", "--trim-tagme")
+	if got, want := readTestNote(t, home, "mixed"), "# Mixed\n\n#real\n"; got != want {
+		t.Errorf("mixed = %q, want %q", got, want)
+	}
+	if got := readTestNote(t, home, "only"); got != notes["only"] {
+		t.Errorf("only = %q, want it unchanged", got)
+	}
+}
+
+func TestAddTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no tags", "# A\n\nText\n", "# A\n\nText\n\n#foo\n"},
+		{"no trailing newline", "# A", "# A\n\n#foo\n"},
+		{"tag line", "# A\n\n#bar #baz  \n\nText\n", "# A\n\n#bar #baz #foo\n\nText\n"},
+		{"last tag line", "#one\n\nText\n\n#two\n", "#one\n\nText\n\n#two #foo\n"},
+		{"tag in text", "# A\n\nText with #bar\n", "# A\n\nText with #bar\n\n#foo\n"},
+		{"frontmatter", "---\ntags: [bar]\n---\n# A\n", "---\ntags: [bar, foo]\n---\n# A\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addTag(tt.content, "foo"); got != tt.want {
+				t.Errorf("addTag(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagCommand(t *testing.T) {
+	home := testVault(t, map[string]string{"a": "# A\n\n#bar\n"})
+
+	tests := []struct {
+		args    []string
+		code    int
+		content string
+	}{
+		{[]string{"tag", "add", "a", "#foo"}, 0, "# A\n\n#bar #foo\n"},
+		{[]string{"tag", "add", "a", "foo"}, 0, "# A\n\n#bar #foo\n"},
+		{[]string{"--dry-run", "tag", "remove", "a", "bar"}, 0, "# A\n\n#bar #foo\n"},
+		{[]string{"tag", "remove", "a", "bar"}, 0, "# A\n\n#foo\n"},
+		{[]string{"tag", "remove", "a", "bar"}, exitNotFound, "# A\n\n#foo\n"},
+		{[]string{"tag", "remove", "missing", "foo"}, exitNotFound, "# A\n\n#foo\n"},
+		{[]string{"tag", "add", "a", "not a tag"}, exitUsage, "# A\n\n#foo\n"},
+		{[]string{"tag", "add", "a"}, exitUsage, "# A\n\n#foo\n"},
+	}
+	for _, tt := range tests {
+		out, code := runZettel(t, home, tt.args...)
+		if code != tt.code {
+			t.Errorf("%v exited %d, want %d; output %q", tt.args, code, tt.code, out)
+		}
+		if got := readTestNote(t, home, "a"); got != tt.content {
+			t.Errorf("after %v a = %q, want %q", tt.args, got, tt.content)
+		}
+	}
+}
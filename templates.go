@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const templatesDir = ".templates"
+
+// availableTemplates lists the template names found in the vault's
+// templates directory.
+func availableTemplates(zettelHome string) []string {
+	paths, _ := filepath.Glob(filepath.Join(zettelHome, templatesDir, "*"+noteExtension))
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, noteID(path))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templatePath resolves a template name to its file in the templates
+// directory, listing the available templates when it does not exist.
+func templatePath(zettelHome, name string) (string, error) {
+	path := filepath.Join(zettelHome, templatesDir, name+noteExtension)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		available := availableTemplates(zettelHome)
+		if len(available) == 0 {
+			return "", fmt.Errorf("template %q not found; %s has no templates", name, filepath.Join(zettelHome, templatesDir))
+		}
+		return "", fmt.Errorf("template %q not found; available: %s", name, strings.Join(available, ", "))
+	}
+	return path, nil
+}
+
+// renderTemplate executes the template file at path. Templates refer to the
+// note with {{title}}, {{id}} and {{date}}, and may use any text/template
+// construct such as {{if title}}...{{end}}.
+func renderTemplate(path, id, title string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	created, ok := noteCreated(id)
+	if !ok {
+		created = time.Now()
+	}
+	funcs := template.FuncMap{
+		"title": func() string { return title },
+		"id":    func() string { return id },
+		"date":  func() string { return created.Format("2006-01-02") },
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(funcs).Parse(string(data))
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, nil); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// vaultKeyPrefix starts the config keys that register vaults, as in
+// vault.work = "~/work-notes".
+const vaultKeyPrefix = "vault."
+
+// vaultName is set by the --vault flag.
+var vaultName string
+
+var validVaultName = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+func setVault(c *config, name, path string) error {
+	if !validVaultName.MatchString(name) {
+		return fmt.Errorf("%q is not a valid vault name", name)
+	}
+	if path == "" {
+		return errors.New("vault path is empty")
+	}
+	if c.Vaults == nil {
+		c.Vaults = map[string]string{}
+	}
+	c.Vaults[name] = path
+	return nil
+}
+
+// vaultDir resolves a vault name to its directory: the path registered in
+// config, or else the vaults directory next to the config file.
+func vaultDir(name string) (string, error) {
+	if path, ok := cfg.Vaults[name]; ok {
+		return expandHome(path)
+	}
+	if !validVaultName.MatchString(name) {
+		return "", fmt.Errorf("%q is not a valid vault name", name)
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "vaults", name), nil
+}
+
+func listVaults() error {
+	names := make([]string, 0, len(cfg.Vaults))
+	for name := range cfg.Vaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if jsonOutput {
+		type vault struct {
+			Name string `json:"name"`
+			Path string `json:"path"`
+		}
+		vaults := make([]vault, 0, len(names))
+		for _, name := range names {
+			vaults = append(vaults, vault{name, cfg.Vaults[name]})
+		}
+		return printJSON(vaults)
+	}
+
+	for _, name := range names {
+		fmt.Printf("%s\t%s\n", name, cfg.Vaults[name])
+	}
+	return nil
+}
+
+// addVault registers name as path in the config file, replacing an existing
+// entry for it and leaving the rest of the file untouched.
+func addVault(name, path string) error {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("Error resolving path: %w", err)
+		}
+		path = abs
+	}
+	if err := setVault(&cfg, name, path); err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+
+	configFile, err := configPath()
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+	content, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error reading config: %w", err)
+	}
+
+	entry := vaultKeyPrefix + name + " = " + strconv.Quote(path)
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(content) == 0 {
+		lines = nil
+	}
+	replaced := false
+	for i, line := range lines {
+		key, _, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == vaultKeyPrefix+name {
+			lines[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, entry)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return fmt.Errorf("Error creating config directory: %w", err)
+	}
+	if err := writeFileAtomic(configFile, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return fmt.Errorf("Error writing config: %w", err)
+	}
+	fmt.Printf("Added vault %s: %s\n", name, path)
+	return nil
+}